@@ -0,0 +1,145 @@
+package config
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/logger"
+)
+
+var (
+	aliasMu sync.RWMutex
+	// aliases deprecated key path to new key path
+	aliases = make(map[string]Path)
+	// warned deprecated key paths which have been warned about
+	warned = make(map[string]bool)
+	// deprecationLogger logger for deprecation warnings and background errors
+	deprecationLogger logger.Logger
+)
+
+// RegisterAlias registers oldKey as a deprecated alias of newKey, keys under
+// oldKey are redirected as well. Reading through oldKey warns once, so does reading
+// through newKey a value which is still stored under oldKey.
+func RegisterAlias(oldKey, newKey string) error {
+	oldPath, err := ParsePath(oldKey)
+	if err != nil {
+		return err
+	}
+	newPath, err := ParsePath(newKey)
+	if err != nil {
+		return err
+	}
+	aliasMu.Lock()
+	defer aliasMu.Unlock()
+	aliases[oldPath.String()] = newPath
+	return nil
+}
+
+// SetLogger sets the logger used for deprecation warnings and background errors, the standard logger is used by default
+func SetLogger(l logger.Logger) {
+	aliasMu.Lock()
+	defer aliasMu.Unlock()
+	deprecationLogger = l
+}
+
+// resolveAlias returns the path with the longest matching alias prefix replaced
+// and reports whether an alias has been matched
+func resolveAlias(path Path) (Path, bool) {
+	aliasMu.RLock()
+	if len(aliases) == 0 {
+		aliasMu.RUnlock()
+		return path, false
+	}
+	for i := len(path); i > 0; i-- {
+		oldKey := path[:i].String()
+		newPath, ok := aliases[oldKey]
+		if !ok {
+			continue
+		}
+		aliasMu.RUnlock()
+		resolved := make(Path, 0, len(newPath)+len(path)-i)
+		resolved = append(append(resolved, newPath...), path[i:]...)
+		warnDeprecated(oldKey, newPath.String())
+		return resolved, true
+	}
+	aliasMu.RUnlock()
+	return path, false
+}
+
+// deprecatedAlias a path spelled with a deprecated key
+type deprecatedAlias struct {
+	path   Path
+	oldKey string
+	newKey string
+}
+
+// deprecatedAliases returns the path spelled with each deprecated key registered for it or for
+// one of its parents, so that values still stored under deprecated keys are found by the new keys
+func deprecatedAliases(path Path) []deprecatedAlias {
+	aliasMu.RLock()
+	defer aliasMu.RUnlock()
+	var found []deprecatedAlias
+	for oldKey, newPath := range aliases {
+		if !hasPrefix(path, newPath) {
+			continue
+		}
+		oldPath, err := ParsePath(oldKey)
+		if err != nil {
+			continue
+		}
+		p := make(Path, 0, len(oldPath)+len(path)-len(newPath))
+		p = append(append(p, oldPath...), path[len(newPath):]...)
+		found = append(found, deprecatedAlias{path: p, oldKey: oldKey, newKey: newPath.String()})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].oldKey < found[j].oldKey
+	})
+	return found
+}
+
+// hasPrefix reports whether prefix is a leading part of the path
+func hasPrefix(path Path, prefix Path) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, s := range prefix {
+		if path[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+func warnDeprecated(oldKey, newKey string) {
+	aliasMu.Lock()
+	defer aliasMu.Unlock()
+	if warned[oldKey] {
+		return
+	}
+	warned[oldKey] = true
+	if deprecationLogger != nil {
+		deprecationLogger.Warnf("config key `%s` is deprecated, use `%s` instead", oldKey, newKey)
+		return
+	}
+	log.Printf("[WARN] config key `%s` is deprecated, use `%s` instead", oldKey, newKey)
+}
+
+// logError logs an error which happened in the background
+func logError(err error) {
+	aliasMu.RLock()
+	l := deprecationLogger
+	aliasMu.RUnlock()
+	if l != nil {
+		l.Errorf("config: %v", err)
+		return
+	}
+	log.Printf("[ERROR] config: %v", err)
+}
+
+// normalizeKey folds case and removes `_` and `-` so that
+// `maxConns`, `max_conns` and `MaxConns` are the same key
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/logger"
+)
+
+// warnLogger records warnings, other levels are not used
+type warnLogger struct {
+	logger.Logger
+	warnings []string
+}
+
+func (l *warnLogger) Warnf(format string, args ...any) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+// registerTestAlias registers the alias and the warning logger until the test ends
+func registerTestAlias(t *testing.T, oldKey, newKey string) *warnLogger {
+	t.Helper()
+	if err := RegisterAlias(oldKey, newKey); err != nil {
+		t.Fatal(err)
+	}
+	l := &warnLogger{}
+	SetLogger(l)
+	t.Cleanup(func() {
+		aliasMu.Lock()
+		delete(aliases, MustParsePath(oldKey).String())
+		delete(warned, MustParsePath(oldKey).String())
+		deprecationLogger = nil
+		aliasMu.Unlock()
+	})
+	return l
+}
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		name         string
+		document     string
+		key          string
+		want         any
+		wantWarnings int
+	}{
+		{
+			name:         "old key reads new key",
+			document:     "[db]\nmax_conns = 10\n",
+			key:          "database.maxConns",
+			want:         int64(10),
+			wantWarnings: 1,
+		},
+		{
+			name:         "new key reads old key",
+			document:     "[database]\nmaxConns = 10\n",
+			key:          "db.max_conns",
+			want:         int64(10),
+			wantWarnings: 1,
+		},
+		{
+			name:     "new key reads new key",
+			document: "[db]\nmax_conns = 10\n",
+			key:      "db.max_conns",
+			want:     int64(10),
+		},
+		{
+			name:         "old key reads old key",
+			document:     "[database]\nmaxConns = 10\n",
+			key:          "database.maxConns",
+			want:         int64(10),
+			wantWarnings: 1,
+		},
+		{
+			name:     "new key prefers new key",
+			document: "[database]\nmaxConns = 5\n[db]\nmax_conns = 10\n",
+			key:      "db.max_conns",
+			want:     int64(10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := registerTestAlias(t, "database.maxConns", "db.max_conns")
+			c := newTestConfig(t, "config.toml", tt.document)
+			for i := 0; i < 2; i++ {
+				got, err := c.Get(tt.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+				}
+			}
+			if len(l.warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", l.warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestAliasSection(t *testing.T) {
+	registerTestAlias(t, "old", "server")
+	c := newTestConfig(t, "config.toml", "[old]\nport = 8080\n")
+	if got, err := c.Get("server.port"); err != nil || got != int64(8080) {
+		t.Errorf("Get(server.port) = %v, %v, want 8080", got, err)
+	}
+}
+
+func TestNormalizedKeys(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "[server]\nmax_conns = 10\n", WithNormalizedKeys())
+	for _, key := range []string{"server.max_conns", "server.maxConns", "Server.MaxConns", "server.max-conns"} {
+		t.Run(key, func(t *testing.T) {
+			if got, err := c.Get(key); err != nil || got != int64(10) {
+				t.Errorf("Get(%q) = %v, %v, want 10", key, got, err)
+			}
+		})
+	}
+	if err := c.Set("server.maxConns", int64(20)); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.AllKeys(); len(got) != 1 || got[0] != "server.max_conns" {
+		t.Errorf("AllKeys() = %v, want the existing key to be updated", got)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/json"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// jsonDecoder a custom decoder, it must not be replaced by the codec of the extension
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(b []byte) (map[string]configer.Field, error) {
+	return json.Decode(b)
+}
+
+func TestCodecSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		document string
+		opts     []Option
+		wantErr  bool
+	}{
+		{name: "extension", file: "config.yaml", document: "server:\n  port: 8080\n"},
+		{name: "codec overrides extension", file: "config.toml", document: "{\"server\": {\"port\": 8080}}", opts: []Option{WithCodec("json")}},
+		{name: "codec alias", file: "config", document: "server:\n  port: 8080\n", opts: []Option{WithCodec("yaml")}},
+		{name: "unknown codec", file: "config", document: "", opts: []Option{WithCodec("csv")}, wantErr: true},
+		{name: "custom decoder", file: "config.toml", document: "{\"server\": {\"port\": 8080}}", opts: []Option{WithDecoder(jsonDecoder{})}},
+		{name: "unknown extension detects", file: "config.conf", document: "[server]\nport = 8080\n"},
+		{name: "no extension detects", file: "config", document: "{\"server\": {\"port\": 8080}}"},
+		{name: "auto detects", file: "config.toml", document: "server:\n  port: 8080\n", opts: []Option{WithCodec(encoding.Auto.String())}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.file, tt.document)
+			cfg, err := NewConfig(append([]Option{WithFilePath(path)}, tt.opts...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if port, err := cfg.Get("server.port"); err != nil || field.Normalize(port) != int64(8080) {
+				t.Errorf("Get(server.port) = %#v, %v, want 8080", port, err)
+			}
+		})
+	}
+}
+
+func TestRemoteContentType(t *testing.T) {
+	documents := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"/json": {contentType: "application/json; charset=utf-8", body: `{"name": "json"}`},
+		"/yaml": {contentType: "application/yaml", body: "name: yaml\n"},
+		"/text": {contentType: "text/plain", body: "name = \"toml\"\n"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		document := documents[r.URL.Path]
+		w.Header().Set("Content-Type", document.contentType)
+		_, _ = w.Write([]byte(document.body))
+	}))
+	defer server.Close()
+	tests := []struct {
+		path    string
+		opts    []Option
+		want    string
+		wantErr bool
+	}{
+		{path: "/json", want: "json"},
+		{path: "/yaml", want: "yaml"},
+		{path: "/text", want: "toml"},
+		{path: "/yaml", opts: []Option{WithCodec("json")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			u, err := url.Parse(server.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := NewConfig(append([]Option{WithSourceURL(u)}, tt.opts...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got, err := cfg.GetString("name"); err != nil || got != tt.want {
+				t.Errorf("GetString(name) = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,24 @@
 package config
 
 import (
+	"crypto/ed25519"
 	"errors"
 	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
 	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
 	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/sign"
 	"github.com/jacksonCLyu/ridi-config/pkg/config/strategy"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 	"github.com/jacksonCLyu/ridi-faces/pkg/env"
@@ -22,7 +28,6 @@ import (
 func Init(opts ...InitOption) error {
 	var gErr error
 	once.Do(func() {
-		encoding.Init()
 		initOpts := &initOptions{}
 		for _, opt := range opts {
 			opt.initApply(initOpts)
@@ -43,7 +48,7 @@ func Init(opts ...InitOption) error {
 func DefaultOptions() *options {
 	return &options{
 		filePath:          "./config.toml",
-		reloadingStrategy: strategy.DefaultFileChangedReloadingStrategy,
+		reloadingStrategy: strategy.NewFileChangedReloadingStrategy(),
 		fileSystem:        filesystem.DefaultFileSystem,
 		sourceURL:         &url.URL{Scheme: "file", Path: fixPath("./config.toml")},
 		encoder:           encoding.DefaultCodec,
@@ -70,6 +75,9 @@ func L() configer.Configurable {
 var _ configer.Configurable = (*config)(nil)
 var _ configer.FileConfiguration = (*config)(nil)
 
+// ErrNotSupported the operation is not supported by the configuration
+var ErrNotSupported = errors.New("not supported")
+
 type config struct {
 	// lock for syncing
 	sync.RWMutex
@@ -83,9 +91,39 @@ type config struct {
 	fileSystem filesystem.FileSystem
 	// configMap config map
 	configMap map[string]configer.Field
+	// overrides runtime overrides which are never persisted
+	overrides map[string]configer.Field
+	// defaults embedded defaults which missing keys fall back to
+	defaults map[string]configer.Field
+	// validators validate staged updates before committing
+	validators []Validator
+	// normalizeKeys compare keys case, `_` and `-` insensitive
+	normalizeKeys bool
+	// persist persists committed updates instead of saving the file when set
+	persist func(from, to map[string]configer.Field) error
+	// loader loads the config map on reload instead of decoding the file when set
+	loader func() (map[string]configer.Field, error)
+	// source the last loaded document, encoded on top of by preserving encoders
+	source []byte
 	// codec codec
 	encoder configer.Encoder
 	decoder configer.Decoder
+	// decrypter decrypts encrypted values when they are read
+	decrypter crypt.Decrypter
+	decrypted decryptCache
+	// secrets resolves secret references when they are read
+	secrets *secret.Resolver
+	// trustedKeys the keys loaded documents must be signed with when not empty
+	trustedKeys []ed25519.PublicKey
+	// codecExplicit the codec was given by options instead of being detected,
+	// the Content-Type of remote sources is ignored then
+	codecExplicit bool
+	// profile the active profile of codecs with profile documents
+	profile string
+	// prefix the key path of a section, encrypted values are bound to their full key path
+	prefix Path
+	// watchers the watched keys notified of changes
+	watchers watchers
 }
 
 // NewConfig creates a new configuration
@@ -94,11 +132,15 @@ func NewConfig(opts ...Option) (configer.Configurable, error) {
 	for _, opt := range opts {
 		opt.apply(options)
 	}
-	if options.filePath == "" {
-		return nil, errors.New("options config `filePath` is empty")
-	}
-	if filepath.Ext(options.filePath) == "" || filepath.Ext(options.filePath) == "." {
-		return nil, errors.New("options config `filePath` file ext not found")
+	remote := options.filePath == "" || options.sourceURL != nil && options.sourceURL.Scheme != "" && options.sourceURL.Scheme != "file"
+	if remote {
+		if options.sourceURL == nil {
+			return nil, errors.New("options config `filePath` is empty")
+		}
+		options.filePath = options.sourceURL.String()
+		if !options.reloadingSet {
+			options.reloadingStrategy = nil
+		}
 	}
 	c := &config{
 		FilePath:       options.filePath,
@@ -106,38 +148,99 @@ func NewConfig(opts ...Option) (configer.Configurable, error) {
 		SourceURL:      options.sourceURL,
 		fileSystem:     options.fileSystem,
 		configMap:      make(map[string]configer.Field),
+		overrides:      make(map[string]configer.Field),
+		validators:     options.validators,
+		normalizeKeys:  options.normalizeKeys,
+		decrypter:      options.decrypter,
+		secrets:        newSecretResolver(options),
+		trustedKeys:    options.trustedKeys,
+	}
+	if err := c.initCodec(options, remote); err != nil {
+		return nil, err
 	}
-	// auto codec
-	ext := filepath.Ext(c.FilePath)
-	ext = ext[1:]
-	if !encoding.IsSupport(ext) {
-		if options.encoder == nil && options.decoder == nil {
-			return nil, errors.New("options config `filePath` file ext not support")
-		}
-		// reset if given custom encoder or decoder
-		if options.encoder != nil {
-			c.SetEncoder(options.encoder)
-		}
-		if options.decoder != nil {
-			c.SetDecoder(options.decoder)
-		}
+	defaults, err := loadDefaults(options.defaults)
+	if err != nil {
+		return nil, err
+	}
+	c.defaults = defaults
+	if remote {
+		err = c.LoadRemote(c.SourceURL)
+	} else {
+		err = c.Load(c.FilePath)
 	}
-	supportCodec := encoding.GetSupport(ext)
-	c.encoder = supportCodec
-	c.decoder = supportCodec
-	err := c.Load(c.FilePath)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.initReloadStrategy(); err != nil {
+		return nil, err
+	}
 	return c, nil
 }
 
+// initCodec resolves the codec: the codec named by WithCodec, then a custom encoder or decoder,
+// then the file extension, otherwise the format is detected from the content
+func (c *config) initCodec(options *options, remote bool) error {
+	c.profile = options.profile
+	switch {
+	case options.codec == encoding.Auto.String():
+		codec := encoding.NewAutoCodec()
+		c.encoder, c.decoder = codec, codec
+		c.codecExplicit = true
+	case options.codec != "":
+		codec, ok := encoding.Lookup(options.codec)
+		if !ok {
+			return errors.New("options config codec `" + options.codec + "` not support")
+		}
+		codec = c.forProfile(codec)
+		c.encoder, c.decoder = codec, codec
+		c.codecExplicit = true
+	case options.customCodec:
+		c.encoder, c.decoder = options.encoder, options.decoder
+		c.codecExplicit = true
+	default:
+		ext := filepath.Ext(c.FilePath)
+		if remote {
+			ext = path.Ext(c.SourceURL.Path)
+		}
+		if _, codec, ok := encoding.ByExtension(ext); ok {
+			codec = c.forProfile(codec)
+			c.encoder, c.decoder = codec, codec
+			return nil
+		}
+		codec := encoding.NewAutoCodec()
+		c.encoder, c.decoder = codec, codec
+	}
+	return nil
+}
+
+// forProfile returns the codec for the active profile if the codec has profile documents
+func (c *config) forProfile(codec configer.Codec) configer.Codec {
+	if profiler, ok := codec.(encoding.Profiler); ok && c.profile != "" {
+		return profiler.ForProfile(c.profile)
+	}
+	return codec
+}
+
+// initReloadStrategy binds the reloading strategy to the configuration and its file system
+func (c *config) initReloadStrategy() error {
+	if c.ReloadStrategy == nil {
+		return nil
+	}
+	if aware, ok := c.ReloadStrategy.(strategy.FileSystemAware); ok {
+		aware.SetFileSystem(c.fileSystem)
+	}
+	c.ReloadStrategy.SetConfiguration(c)
+	return c.ReloadStrategy.Init()
+}
+
 func (c *config) GetEncoder() configer.Encoder {
 	return c.encoder
 }
 
 func (c *config) SetEncoder(encoder configer.Encoder) {
 	c.encoder = encoder
+	// the loaded document may be in another format
+	c.source = nil
 }
 
 func (c *config) GetDecoder() configer.Decoder {
@@ -155,7 +258,14 @@ func (c *config) Load(path string) error {
 	if err != nil {
 		return err
 	}
-	return c.LoadStream(reader)
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var detached []byte
+	if len(c.trustedKeys) > 0 {
+		detached = readSignature(c.fileSystem.GetReader(path + sign.Ext))
+	}
+	return c.loadStream(reader, detached)
 }
 
 // LoadRemote load configuration from url
@@ -170,50 +280,147 @@ func (c *config) LoadRemote(url *url.URL) error {
 	if err != nil {
 		return err
 	}
-	return c.LoadStream(is)
+	if closer, ok := is.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if typer, ok := is.(filesystem.ContentTyper); ok && !c.codecExplicit {
+		if _, codec, ok := encoding.ByMIME(typer.ContentType()); ok {
+			codec = c.forProfile(codec)
+			c.Lock()
+			c.encoder, c.decoder = codec, codec
+			c.Unlock()
+		}
+	}
+	var detached []byte
+	if len(c.trustedKeys) > 0 {
+		sigURL := *url
+		sigURL.Path, sigURL.RawPath = sigURL.Path+sign.Ext, ""
+		detached = readSignature(c.fileSystem.GetReaderFromURL(&sigURL))
+	}
+	return c.loadStream(is, detached)
 }
 
 func (c *config) LoadStream(r io.Reader) error {
+	return c.loadStream(r, nil)
+}
+
+// loadStream decodes the stream, with trusted keys it is verified against the detached
+// signature if not nil, otherwise against the embedded signature
+func (c *config) loadStream(r io.Reader, detached []byte) error {
+	changes, err := c.decodeStream(r, detached)
+	if err != nil {
+		return err
+	}
+	c.notify(changes)
+	return nil
+}
+
+// decodeStream decodes the stream and returns the changes of the watched keys
+func (c *config) decodeStream(r io.Reader, detached []byte) ([]Change, error) {
 	c.Lock()
 	defer c.Unlock()
-	c.configMap = make(map[string]configer.Field)
 	all, err := ioutil.ReadAll(r)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	c.configMap, err = c.decoder.Decode(all)
-	return err
+	if len(c.trustedKeys) > 0 {
+		if all, err = sign.Verify(c.trustedKeys, all, detached); err != nil {
+			return nil, err
+		}
+	}
+	configMap, err := c.decoder.Decode(all)
+	if err != nil {
+		return nil, err
+	}
+	before := c.snapshot()
+	c.configMap = configMap
+	c.source = all
+	c.decrypted.reset()
+	return c.changes(before), nil
 }
 
 func (c *config) Save(path string) error {
-	open, err := os.Open(path)
+	c.RLock()
+	defer c.RUnlock()
+	return c.save(path)
+}
+
+// save This method does not acquire the lock. The content is encoded before the file is
+// opened, so a failed encoding leaves the file untouched
+func (c *config) save(path string) error {
+	all, err := c.encode()
 	if err != nil {
 		return err
 	}
-	if writer, err := c.fileSystem.GetWriter(open); err != nil {
+	if pathWriter, ok := c.fileSystem.(filesystem.PathWriter); ok {
+		writer, err := pathWriter.GetWriterFromPath(path)
+		if err != nil {
+			return err
+		}
+		return writeAll(writer, all)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if writer, err := c.fileSystem.GetWriter(file); err != nil {
 		return err
 	} else {
-		return c.SaveStream(writer)
+		_, err = writer.Write(all)
+		return err
 	}
 }
 
 func (c *config) SaveStream(writer io.Writer) error {
-	c.Lock()
-	defer c.Unlock()
-	if all, err := c.encoder.Encode(c.configMap); err != nil {
-		return err
-	} else {
-		_, err := writer.Write(all)
+	c.RLock()
+	defer c.RUnlock()
+	return c.saveStream(writer)
+}
+
+func (c *config) saveStream(writer io.Writer) error {
+	all, err := c.encode()
+	if err != nil {
 		return err
 	}
+	_, err = writer.Write(all)
+	return err
+}
+
+// encode encodes the config map without the defaults, on top of the source if the encoder preserves it
+func (c *config) encode() ([]byte, error) {
+	if preserving, ok := c.encoder.(encoding.PreservingEncoder); ok && c.source != nil {
+		return preserving.EncodePreserving(c.source, diffDefaults(c.configMap, c.defaults))
+	}
+	return c.encoder.Encode(diffDefaults(c.configMap, c.defaults))
 }
 
 func (c *config) SaveRemote(url *url.URL) error {
+	c.RLock()
+	all, err := c.encode()
+	c.RUnlock()
+	if err != nil {
+		return err
+	}
 	fromURL, err := c.fileSystem.GetWriterFromURL(url)
 	if err != nil {
 		return err
 	}
-	return c.SaveStream(fromURL)
+	return writeAll(fromURL, all)
+}
+
+// writeAll writes the content and closes the writer if it is an io.Closer
+func writeAll(writer io.Writer, all []byte) error {
+	if _, err := writer.Write(all); err != nil {
+		if closer, ok := writer.(io.Closer); ok {
+			closer.Close()
+		}
+		return err
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func (c *config) GetFileName() string {
@@ -244,45 +451,92 @@ func (c *config) SetURL(url *url.URL) {
 	c.SourceURL = url
 }
 
-func (c *config) Merge(config configer.FileConfiguration) error {
-	c.Lock()
-	defer c.Unlock()
-	// TODO merge implementation
-	return nil
+// Merge merges the values of the given configuration into the current one, values of the given
+// configuration take precedence. The merged configuration is validated and persisted once.
+func (c *config) Merge(other configer.FileConfiguration) error {
+	var src map[string]configer.Field
+	switch o := other.(type) {
+	case *config:
+		o.RLock()
+		src = field.CopyMap(o.configMap)
+		o.RUnlock()
+	case interface{ AllSettings() map[string]any }:
+		f := configer.Atof(o.AllSettings())
+		src, _ = f.Value.(map[string]configer.Field)
+	default:
+		return errors.New("merge configuration: " + ErrNotSupported.Error())
+	}
+	return c.Update(func(tx *Tx) error {
+		field.MergeMap(tx.configMap, src)
+		tx.dirty = true
+		return nil
+	})
 }
 
+// Sync writes the current configuration to its file or store
 func (c *config) Sync() error {
 	c.Lock()
 	defer c.Unlock()
-	// TODO sync implementation
-	return nil
-}
-
-func (c *config) Watch(paths ...string) error {
-	c.Lock()
-	defer c.Unlock()
-	// TODO watch implementation
-	return nil
+	return c.commit(c.configMap)
 }
 
 func (c *config) Reload() error {
-	c.RLock()
-	defer c.RUnlock()
-	var needReload bool
-	var err error
-	if needReload, err = c.ReloadStrategy.NeedReloading(); err != nil {
+	reloadStrategy := c.GetReloadStrategy()
+	if reloadStrategy == nil {
+		return nil
+	}
+	needReload, err := reloadStrategy.NeedReloading()
+	if err != nil {
 		return err
 	}
 	if !needReload {
 		return nil
 	}
-	c.Lock()
-	defer c.Unlock()
-	reader, err := c.fileSystem.GetReader(c.FilePath)
-	if err != nil {
+	if c.loader != nil {
+		configMap, err := c.loader()
+		if err != nil {
+			return err
+		}
+		c.Lock()
+		before := c.snapshot()
+		c.configMap = configMap
+		c.decrypted.reset()
+		changes := c.changes(before)
+		c.Unlock()
+		c.notify(changes)
+	} else if c.isRemote() {
+		if err := c.LoadRemote(c.GetURL()); err != nil {
+			return err
+		}
+	} else if err := c.Load(c.GetFilePath()); err != nil {
 		return err
 	}
-	return c.LoadStream(reader)
+	if c.secrets != nil {
+		c.secrets.Refresh()
+	}
+	return reloadStrategy.ReloadingPerformed()
+}
+
+// readSignature reads the detached signature, nil if it can not be read
+func readSignature(r io.Reader, err error) []byte {
+	if err != nil {
+		return nil
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// isRemote reports whether the configuration is loaded from its source URL instead of a file path
+func (c *config) isRemote() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.SourceURL != nil && c.FilePath == c.SourceURL.String()
 }
 
 func (c *config) GetReloadStrategy() configer.ReloadingStrategy {
@@ -300,12 +554,15 @@ func (c *config) SetReloadStrategy(strategy configer.ReloadingStrategy) {
 func (c *config) ContainsKey(key string) bool {
 	c.RLock()
 	defer c.RUnlock()
-	return containsKey(c.configMap, key)
+	path, err := ParsePath(key)
+	if err != nil {
+		return false
+	}
+	_, _, ok := c.find(path)
+	return ok
 }
 
 func (c *config) GetString(key string) (string, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return "", err
@@ -314,12 +571,13 @@ func (c *config) GetString(key string) (string, error) {
 	if field.Type != t {
 		return "", errors.New("field type is not " + t.String())
 	}
+	if v, ok := field.Value.(secret.Value); ok {
+		return v.Reveal(), nil
+	}
 	return field.Value.(string), nil
 }
 
 func (c *config) GetInt(key string) (int, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -331,8 +589,6 @@ func (c *config) GetInt(key string) (int, error) {
 }
 
 func (c *config) GetBool(key string) (bool, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return false, err
@@ -344,8 +600,6 @@ func (c *config) GetBool(key string) (bool, error) {
 }
 
 func (c *config) GetFloat64(key string) (float64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0.0, err
@@ -357,8 +611,6 @@ func (c *config) GetFloat64(key string) (float64, error) {
 }
 
 func (c *config) GetStringSlice(key string) ([]string, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []string{}, err
@@ -370,8 +622,6 @@ func (c *config) GetStringSlice(key string) ([]string, error) {
 }
 
 func (c *config) GetIntSlice(key string) ([]int, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []int{}, err
@@ -383,8 +633,6 @@ func (c *config) GetIntSlice(key string) ([]int, error) {
 }
 
 func (c *config) GetBoolSlice(key string) ([]bool, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []bool{}, err
@@ -396,8 +644,6 @@ func (c *config) GetBoolSlice(key string) ([]bool, error) {
 }
 
 func (c *config) GetFloat64Slice(key string) ([]float64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []float64{}, err
@@ -409,8 +655,6 @@ func (c *config) GetFloat64Slice(key string) ([]float64, error) {
 }
 
 func (c *config) GetSection(key string) (configer.Configurable, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return nil, err
@@ -418,12 +662,36 @@ func (c *config) GetSection(key string) (configer.Configurable, error) {
 	if field.Type != configer.FieldTypeSection {
 		return nil, errors.New("field type is not Configurable")
 	}
-	return field.Value.(*config), nil
+	// the key path of the section as found, encrypted values are bound to it
+	c.RLock()
+	_, path, _ := c.find(MustParsePath(key))
+	c.RUnlock()
+	return c.view(field.Value.(map[string]configer.Field), nil, append(c.prefix[:len(c.prefix):len(c.prefix)], path...)), nil
+}
+
+// readOnly a configuration which can only be read, such as a section or a saved version
+type readOnly struct {
+	configer.Configurable
+}
+
+// Set returns an error, the configuration is read only
+func (r readOnly) Set(key string, value any) error {
+	return errors.New("config is read only, key:`" + key + "` can not be set")
+}
+
+// view returns a read only configuration of a copy of the config map, the prefix is the key path of a section
+func (c *config) view(configMap map[string]configer.Field, defaults map[string]configer.Field, prefix Path) configer.Configurable {
+	return readOnly{&config{
+		configMap:     field.CopyMap(configMap),
+		defaults:      defaults,
+		normalizeKeys: c.normalizeKeys,
+		decrypter:     c.decrypter,
+		secrets:       c.secrets,
+		prefix:        prefix,
+	}}
 }
 
 func (c *config) GetInt32(key string) (int32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -436,8 +704,6 @@ func (c *config) GetInt32(key string) (int32, error) {
 }
 
 func (c *config) GetInt32Slice(key string) ([]int32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []int32{}, err
@@ -450,8 +716,6 @@ func (c *config) GetInt32Slice(key string) ([]int32, error) {
 }
 
 func (c *config) GetInt64(key string) (int64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -464,8 +728,6 @@ func (c *config) GetInt64(key string) (int64, error) {
 }
 
 func (c *config) GetInt64Slice(key string) ([]int64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []int64{}, err
@@ -478,8 +740,6 @@ func (c *config) GetInt64Slice(key string) ([]int64, error) {
 }
 
 func (c *config) GetUint(key string) (uint, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -492,8 +752,6 @@ func (c *config) GetUint(key string) (uint, error) {
 }
 
 func (c *config) GetUintSlice(key string) ([]uint, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []uint{}, err
@@ -506,8 +764,6 @@ func (c *config) GetUintSlice(key string) ([]uint, error) {
 }
 
 func (c *config) GetUint32(key string) (uint32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -520,8 +776,6 @@ func (c *config) GetUint32(key string) (uint32, error) {
 }
 
 func (c *config) GetUint32Slice(key string) ([]uint32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []uint32{}, err
@@ -534,8 +788,6 @@ func (c *config) GetUint32Slice(key string) ([]uint32, error) {
 }
 
 func (c *config) GetUint64(key string) (uint64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -548,8 +800,6 @@ func (c *config) GetUint64(key string) (uint64, error) {
 }
 
 func (c *config) GetUint64Slice(key string) ([]uint64, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []uint64{}, err
@@ -562,8 +812,6 @@ func (c *config) GetUint64Slice(key string) ([]uint64, error) {
 }
 
 func (c *config) GetFloat32(key string) (float32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0.0, err
@@ -576,8 +824,6 @@ func (c *config) GetFloat32(key string) (float32, error) {
 }
 
 func (c *config) GetFloat32Slice(key string) ([]float32, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return []float32{}, err
@@ -590,8 +836,6 @@ func (c *config) GetFloat32Slice(key string) ([]float32, error) {
 }
 
 func (c *config) GetDuration(key string) (time.Duration, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return 0, err
@@ -604,8 +848,6 @@ func (c *config) GetDuration(key string) (time.Duration, error) {
 }
 
 func (c *config) GetTime(key string) (time.Time, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return time.Now().Local(), err
@@ -618,8 +860,6 @@ func (c *config) GetTime(key string) (time.Time, error) {
 }
 
 func (c *config) Get(key string) (any, error) {
-	c.RLock()
-	defer c.RUnlock()
 	field, err := c.get(key)
 	if err != nil {
 		return nil, err
@@ -627,46 +867,107 @@ func (c *config) Get(key string) (any, error) {
 	return field.Value, nil
 }
 
-func (c *config) Set(key string, value any) error {
-	c.Lock()
-	defer c.Unlock()
-	c.configMap[key] = configer.Atof(value)
-	return c.Save(c.FilePath)
+// GetPath returns the value at the parsed key path
+func (c *config) GetPath(path Path) (any, error) {
+	field, err := c.getPath(path, path.String())
+	if err != nil {
+		return nil, err
+	}
+	return field.Value, nil
 }
 
-func containsKey(configMap map[string]configer.Field, key string) bool {
-	if strings.Contains(key, ".") {
-		index := strings.Index(key, ".")
-		parentKey := key[:index]
-		v := configMap[parentKey]
-		if v.Type != configer.FieldTypeSection {
-			return false
-		}
-		subKey := key[index+1:]
-		return containsKey(v.Value.(map[string]configer.Field), subKey)
-	}
-	_, ok := configMap[key]
+// ContainsPath returns true if the parsed key path is in the config
+func (c *config) ContainsPath(path Path) bool {
+	c.RLock()
+	defer c.RUnlock()
+	_, _, ok := c.find(path)
 	return ok
 }
 
+func (c *config) Set(key string, value any) error {
+	return c.Update(func(tx *Tx) error {
+		return tx.Set(key, value)
+	})
+}
+
 // get This method acquires the lock by default
 func (c *config) get(key string) (configer.Field, error) {
-	return getRecursive(c.configMap, key)
+	path, err := ParsePath(key)
+	if err != nil {
+		return configer.Field{}, err
+	}
+	return c.getPath(path, key)
+}
+
+// getPath looks up and decrypts the field of the path under the read lock, a secret reference
+// is resolved after releasing it
+func (c *config) getPath(path Path, key string) (configer.Field, error) {
+	c.RLock()
+	field, found, ok := c.find(path)
+	if !ok {
+		c.RUnlock()
+		return configer.Field{}, errors.New("config not found for key:`" + key + "`")
+	}
+	field, err := c.decrypt(found, field)
+	secrets := c.secrets
+	c.RUnlock()
+	if err == nil {
+		field, err = resolveSecret(secrets, field)
+	}
+	if err != nil {
+		return configer.Field{}, errors.New("config key:`" + key + "` " + err.Error())
+	}
+	return field, nil
 }
 
-func getRecursive(configMap map[string]configer.Field, key string) (configer.Field, error) {
-	if strings.Contains(key, ".") {
-		index := strings.Index(key, ".")
-		parentKey := key[:index]
-		v := configMap[parentKey]
-		if v.Type != configer.FieldTypeSection {
-			return configer.Field{}, errors.New("config not found for key:`" + key + "`")
+// find looks up the path in the overrides, the config map and then in the defaults,
+// aliases are resolved first and the deprecated paths are used as a fallback.
+// It returns the path of the field as found in its layer.
+func (c *config) find(path Path) (configer.Field, Path, bool) {
+	paths := []Path{path}
+	if resolved, ok := resolveAlias(path); ok {
+		paths = []Path{resolved, path}
+	}
+	for _, p := range paths {
+		if field, found, ok := c.lookup(p); ok {
+			return field, found, true
 		}
-		subKey := key[index+1:]
-		return getRecursive(v.Value.(map[string]configer.Field), subKey)
 	}
-	if !containsKey(configMap, key) {
-		return configer.Field{}, errors.New("config not found for key:`" + key + "`")
+	for _, alias := range deprecatedAliases(paths[0]) {
+		if field, found, ok := c.lookup(alias.path); ok {
+			warnDeprecated(alias.oldKey, alias.newKey)
+			return field, found, true
+		}
+	}
+	return configer.Field{}, nil, false
+}
+
+// lookup returns the field of the path and its path as found from the overrides, the config map
+// and then the defaults, sections are merged across the layers like the effective settings
+func (c *config) lookup(path Path) (configer.Field, Path, bool) {
+	var value configer.Field
+	var found Path
+	for _, configMap := range []map[string]configer.Field{c.overrides, c.configMap, c.defaults} {
+		p := path
+		if c.normalizeKeys {
+			p = resolvePath(configMap, path)
+		}
+		f, ok := findPath(configMap, p, false)
+		if !ok {
+			continue
+		}
+		if found == nil {
+			value, found = f, p
+			if f.Type != configer.FieldTypeSection {
+				return value, found, true
+			}
+			continue
+		}
+		if f.Type == configer.FieldTypeSection {
+			merged := field.CopyMap(f.Value.(map[string]configer.Field))
+			field.MergeMap(merged, value.Value.(map[string]configer.Field))
+			value = configer.Field{Type: configer.FieldTypeSection, Value: merged}
+		}
 	}
-	return configMap[key], nil
+	return value, found, found != nil
 }
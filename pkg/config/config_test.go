@@ -0,0 +1,317 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/ed25519"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/sign"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/strategy"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+func TestMemFileSystem(t *testing.T) {
+	mem := filesystem.NewMemFS()
+	mem.WriteFile("conf/app.toml", []byte("name = \"first\"\n"))
+	cfg, err := NewConfig(
+		WithFileSystem(mem),
+		WithFilePath("conf/app.toml"),
+		WithReloadingStrategy(strategy.NewFileChangedReloadingStrategy(strategy.WithTriggerInterval(time.Nanosecond))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*config)
+	if got, err := c.GetString("name"); err != nil || got != "first" {
+		t.Fatalf("GetString() = %q, %v, want first", got, err)
+	}
+	if err := c.Set("port", int64(8080)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := mem.ReadFile("conf/app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	mem.WriteFile("conf/app.toml", append(b, []byte("extra = true\n")...))
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetBool("extra"); err != nil || !got {
+		t.Errorf("GetBool() after reload = %v, %v, want true", got, err)
+	}
+	if got, err := c.GetInt64("port"); err != nil || got != 8080 {
+		t.Errorf("GetInt64() after reload = %v, %v, want the saved value", got, err)
+	}
+}
+
+func TestGitFileSystem(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "--bare", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	git := filesystem.NewGitFS(repo, "refs/heads/main")
+	w, err := git.GetWriterFromPath("app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "name = \"first\"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := NewConfig(
+		WithFileSystem(git),
+		WithFilePath("app.toml"),
+		WithReloadingStrategy(strategy.NewRevisionChangedReloadingStrategy(strategy.WithTriggerInterval(time.Nanosecond))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewConfig(WithFileSystem(git), WithFilePath("app.toml"), WithReloadingStrategy(strategy.NewManagedReloadingStrategy()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Set("name", "second"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := cfg.(*config).Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cfg.GetString("name"); err != nil || got != "second" {
+		t.Errorf("GetString() after the ref moved = %q, %v, want second", got, err)
+	}
+}
+
+func TestSaveStreamPreserving(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     string
+	}{
+		{
+			name:     "config.toml",
+			document: "# server\n[server]\nport = 8080 # listen port\nhost = \"localhost\"\n",
+			want:     "# server\n[server]\nport = 9090 # listen port\nhost = \"localhost\"\n",
+		},
+		{
+			name:     "config.yml",
+			document: "# server\nserver:\n  port: 8080 # listen port\n  host: localhost\n",
+			want:     "# server\nserver:\n  port: 9090 # listen port\n  host: localhost\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, tt.name, tt.document)
+			if err := c.Set("server.port", int64(9090)); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := c.SaveStream(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("SaveStream() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	const document = "server:\n  host: localhost\n---\nprofile: prod\nserver:\n  host: prod.example.com\n"
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "no profile", want: "localhost"},
+		{name: "profile", opts: []Option{WithProfile("prod")}, want: "prod.example.com"},
+		{name: "profile with named codec", opts: []Option{WithProfile("prod"), WithCodec("yaml")}, want: "prod.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.yml", document, tt.opts...)
+			if got, err := c.GetString("server.host"); err != nil || got != tt.want {
+				t.Errorf("GetString(server.host) = %q, %v, want %q", got, err, tt.want)
+			}
+			if err := c.Set("server.port", 8080); err == nil {
+				t.Error("Set() on a source of several documents succeeded")
+			}
+			if b, err := os.ReadFile(c.FilePath); err != nil || string(b) != document {
+				t.Errorf("file = %q, %v, want the documents unchanged", b, err)
+			}
+		})
+	}
+	c := newTestConfig(t, "config.yml", "profile: dev\nserver:\n  host: localhost\n")
+	if got, err := c.GetString("profile"); err != nil || got != "dev" {
+		t.Errorf("GetString(profile) of a single document = %q, %v, want dev", got, err)
+	}
+}
+
+func TestTrustedKeys(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	trusted := WithTrustedKeys(key.Public().(ed25519.PublicKey))
+	payload := []byte("[server]\nport = 8080\n")
+	tests := []struct {
+		name     string
+		content  []byte
+		detached []byte
+		wantErr  bool
+	}{
+		{name: "embedded signature", content: sign.Embed(key, payload, "#")},
+		{name: "detached signature", content: payload, detached: sign.Detached(key, payload)},
+		{name: "unsigned", content: payload, wantErr: true},
+		{name: "untrusted key", content: sign.Embed(other, payload, "#"), wantErr: true},
+		{name: "tampered", content: bytes.Replace(sign.Embed(key, payload, "#"), []byte("8080"), []byte("9090"), 1), wantErr: true},
+		{name: "detached signature of another file", content: payload, detached: sign.Detached(key, []byte("a = 1\n")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, "config.toml", string(tt.content))
+			if tt.detached != nil {
+				if err := os.WriteFile(path+sign.Ext, tt.detached, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := NewConfig(WithFilePath(path), WithReloadingStrategy(strategy.NewManagedReloadingStrategy()), trusted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if port, err := c.GetInt64("server.port"); err != nil || port != 8080 {
+				t.Errorf("GetInt64(server.port) = %d, %v, want 8080", port, err)
+			}
+		})
+	}
+}
+
+func TestTrustedKeysReload(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	c := newTestConfig(t, "config.toml", string(sign.Embed(key, []byte("port = 8080\n"), "#")), WithTrustedKeys(key.Public().(ed25519.PublicKey)))
+	if err := os.WriteFile(c.FilePath, []byte("port = 9090\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(c.FilePath); err == nil {
+		t.Fatal("Load() of the unsigned file succeeded")
+	}
+	if port, err := c.GetInt64("port"); err != nil || port != 8080 {
+		t.Errorf("GetInt64(port) = %d, %v, want the values of the signed file", port, err)
+	}
+}
+
+func TestGetSection(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	c := newTestConfig(t, "config.toml", "[db]\nhost = \"localhost\"\npassword = \"s3cret\"\n[db.pool]\nsize = 10\n", WithDecrypter(a))
+	if err := c.EncryptKeys("db.password"); err != nil {
+		t.Fatal(err)
+	}
+	db, err := c.GetSection("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := db.GetSection("pool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		get     func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{name: "value", get: func() (any, error) { return db.GetString("host") }, want: "localhost"},
+		{name: "encrypted value bound to the full key path", get: func() (any, error) { return db.GetString("password") }, want: "s3cret"},
+		{name: "nested section", get: func() (any, error) { return pool.GetInt64("size") }, want: int64(10)},
+		{name: "nested key", get: func() (any, error) { return db.GetInt64("pool.size") }, want: int64(10)},
+		{name: "missing key", get: func() (any, error) { return db.Get("port") }, wantErr: true},
+		{name: "not a section", get: func() (any, error) { return c.GetSection("db.host") }, wantErr: true},
+		{name: "read only", get: func() (any, error) { return nil, db.Set("host", "remote") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if host, err := c.GetString("db.host"); err != nil || host != "localhost" {
+		t.Errorf("GetString(db.host) = %q, %v, want the configuration unchanged", host, err)
+	}
+}
+
+func TestArchiveSource(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.tar")
+	writeArchive := func(content string) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: "conf/app.toml", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(tw, content); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeArchive("port = 8080\n")
+	source := &url.URL{Scheme: "tar", Path: archive, Fragment: "conf/app.toml"}
+	tests := []struct {
+		name     string
+		strategy configer.ReloadingStrategy
+	}{
+		{name: "file changed", strategy: strategy.NewFileChangedReloadingStrategy(strategy.WithTriggerInterval(time.Millisecond))},
+		{name: "managed", strategy: strategy.NewManagedReloadingStrategy()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeArchive("port = 8080\n")
+			c, err := NewConfig(WithFileSystem(filesystem.NewArchiveFS("")), WithSourceURL(source), WithCodec("toml"), WithReloadingStrategy(tt.strategy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if port, err := c.GetInt64("port"); err != nil || port != 8080 {
+				t.Fatalf("GetInt64(port) = %d, %v, want 8080", port, err)
+			}
+			if _, ok := tt.strategy.(*strategy.FileChangedReloadingStrategy); !ok {
+				return
+			}
+			writeArchive("port = 9090\n")
+			later := time.Now().Add(time.Second)
+			if err := os.Chtimes(archive, later, later); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * time.Millisecond)
+			if err := c.(*config).Reload(); err != nil {
+				t.Fatal(err)
+			}
+			if port, err := c.GetInt64("port"); err != nil || port != 9090 {
+				t.Errorf("GetInt64(port) after reload = %d, %v, want 9090", port, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+)
+
+// AES256GCM the cipher name of AES-256 in GCM mode
+const AES256GCM = "AES256_GCM"
+
+var _ Decrypter = (*AESGCM)(nil)
+var _ Encrypter = (*AESGCM)(nil)
+var _ KeyDecrypter = (*AESGCM)(nil)
+var _ KeyEncrypter = (*AESGCM)(nil)
+
+// AESGCM AES-256-GCM cipher, the key is loaded from the provider on first use. Loading is
+// retried on the next use if it fails, such as while a key file is not yet mounted.
+type AESGCM struct {
+	provider KeyProvider
+	mu       sync.Mutex
+	aead     cipher.AEAD
+}
+
+// NewAESGCM new AES-256-GCM cipher with the key of the provider
+func NewAESGCM(provider KeyProvider) *AESGCM {
+	return &AESGCM{provider: provider}
+}
+
+// Decrypt decrypts the encrypted value, values bound to their key path need DecryptKey
+func (a *AESGCM) Decrypt(value string) (any, error) {
+	return a.open(value, "", false)
+}
+
+// DecryptKey decrypts the encrypted value of the key path, values which are not bound are decrypted as by Decrypt
+func (a *AESGCM) DecryptKey(key string, value string) (any, error) {
+	return a.open(value, key, true)
+}
+
+// Encrypt encrypts the plain value with a random iv, the value is not bound to a key path
+func (a *AESGCM) Encrypt(value any) (string, error) {
+	return a.seal(value, "", false)
+}
+
+// EncryptKey encrypts the plain value of the key path with a random iv, the key path is
+// authenticated with the value so it only decrypts with DecryptKey of the same key path
+func (a *AESGCM) EncryptKey(key string, value any) (string, error) {
+	return a.seal(value, key, true)
+}
+
+func (a *AESGCM) open(value string, key string, keyed bool) (any, error) {
+	e, err := ParseEnvelope(value)
+	if err != nil {
+		return nil, err
+	}
+	if e.Cipher != AES256GCM {
+		return nil, errors.New("cipher `" + e.Cipher + "` not support")
+	}
+	if e.BoundKey && !keyed {
+		return nil, errors.New("encrypted value is bound to its key path")
+	}
+	aead, err := a.cipher()
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(e.Data)
+	if err != nil {
+		return nil, errors.New("encrypted value data is invalid: " + err.Error())
+	}
+	iv, err := base64.StdEncoding.DecodeString(e.IV)
+	if err != nil || len(iv) != aead.NonceSize() {
+		return nil, errors.New("encrypted value iv is invalid")
+	}
+	plain, err := aead.Open(nil, iv, data, additionalData(e, key))
+	if err != nil {
+		return nil, errors.New("decrypt value: " + err.Error())
+	}
+	return unmarshal(string(plain), e.Type)
+}
+
+func (a *AESGCM) seal(value any, key string, keyed bool) (string, error) {
+	text, t, err := marshal(value)
+	if err != nil {
+		return "", err
+	}
+	aead, err := a.cipher()
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	e := &Envelope{
+		Cipher:   AES256GCM,
+		IV:       base64.StdEncoding.EncodeToString(iv),
+		Type:     t,
+		BoundKey: keyed,
+	}
+	e.Data = base64.StdEncoding.EncodeToString(aead.Seal(nil, iv, []byte(text), additionalData(e, key)))
+	return e.String(), nil
+}
+
+// additionalData the authenticated data of the value: its type, and its key path if bound
+func additionalData(e *Envelope, key string) []byte {
+	if !e.BoundKey {
+		return []byte(e.Type)
+	}
+	return []byte(e.Type + "\x00" + key)
+}
+
+// cipher returns the cipher of the key, the key is loaded until it has been loaded successfully
+func (a *AESGCM) cipher() (cipher.AEAD, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.aead != nil {
+		return a.aead, nil
+	}
+	key, err := a.provider.Key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if a.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	return a.aead, nil
+}
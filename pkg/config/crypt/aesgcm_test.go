@@ -0,0 +1,188 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testCipher(b byte) *AESGCM {
+	return NewAESGCM(StaticKey(bytes.Repeat([]byte{b}, 32)))
+}
+
+func TestAESGCMRoundTrip(t *testing.T) {
+	a := testCipher(1)
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "string", value: "s3cret", want: "s3cret"},
+		{name: "int", value: 42, want: int64(42)},
+		{name: "float", value: 0.5, want: 0.5},
+		{name: "bool", value: true, want: true},
+		{name: "uint64", value: uint64(math.MaxInt64), want: int64(math.MaxInt64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := a.Encrypt(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := a.Decrypt(encrypted); err != nil || got != tt.want {
+				t.Errorf("Decrypt() = %v, %v, want %v", got, err, tt.want)
+			}
+			bound, err := a.EncryptKey("db.password", tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(bound, ",aad:key]") {
+				t.Errorf("EncryptKey() = %q, want a value bound to its key", bound)
+			}
+			if got, err := a.DecryptKey("db.password", bound); err != nil || got != tt.want {
+				t.Errorf("DecryptKey() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAESGCMRejects(t *testing.T) {
+	a := testCipher(1)
+	bound, err := a.EncryptKey("db.password", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbound, err := a.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := func(value string) string {
+		e, err := ParseEnvelope(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := base64.StdEncoding.DecodeString(e.Data)
+		data[0] ^= 1
+		e.Data = base64.StdEncoding.EncodeToString(data)
+		return e.String()
+	}
+	retyped := func(value string) string {
+		e, err := ParseEnvelope(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Type = TypeInt
+		return e.String()
+	}
+	unbind := func(value string) string {
+		e, err := ParseEnvelope(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.BoundKey = false
+		return e.String()
+	}
+	tests := []struct {
+		name    string
+		decrypt func() (any, error)
+	}{
+		{name: "tampered ciphertext", decrypt: func() (any, error) { return a.Decrypt(tampered(unbound)) }},
+		{name: "tampered bound ciphertext", decrypt: func() (any, error) { return a.DecryptKey("db.password", tampered(bound)) }},
+		{name: "tampered type", decrypt: func() (any, error) { return a.Decrypt(retyped(unbound)) }},
+		{name: "wrong key", decrypt: func() (any, error) { return testCipher(2).Decrypt(unbound) }},
+		{name: "wrong key bound", decrypt: func() (any, error) { return testCipher(2).DecryptKey("db.password", bound) }},
+		{name: "moved to another key path", decrypt: func() (any, error) { return a.DecryptKey("db.user", bound) }},
+		{name: "bound without key path", decrypt: func() (any, error) { return a.Decrypt(bound) }},
+		{name: "binding removed", decrypt: func() (any, error) { return a.Decrypt(unbind(bound)) }},
+		{name: "unknown aad", decrypt: func() (any, error) {
+			return a.DecryptKey("db.password", strings.Replace(bound, "aad:key", "aad:path", 1))
+		}},
+		{name: "not encrypted", decrypt: func() (any, error) { return a.Decrypt("s3cret") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.decrypt(); err == nil {
+				t.Errorf("decrypt = %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestAESGCMOverflow(t *testing.T) {
+	for _, value := range []any{uint64(math.MaxInt64) + 1, uint64(math.MaxUint64)} {
+		if encrypted, err := testCipher(1).Encrypt(value); err == nil {
+			t.Errorf("Encrypt(%d) = %q, want an error", value, encrypted)
+		}
+	}
+}
+
+func TestAESGCMKeyRetry(t *testing.T) {
+	var key []byte
+	calls := 0
+	a := NewAESGCM(KeyProviderFunc(func() ([]byte, error) {
+		calls++
+		if key == nil {
+			return nil, errors.New("key not mounted")
+		}
+		return key, nil
+	}))
+	if _, err := a.Encrypt("s3cret"); err == nil {
+		t.Fatal("Encrypt() without a key succeeded")
+	}
+	key = bytes.Repeat([]byte{1}, KeySize)
+	encrypted, err := a.Encrypt("s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt() after the key is available error = %v", err)
+	}
+	if got, err := a.Decrypt(encrypted); err != nil || got != "s3cret" {
+		t.Errorf("Decrypt() = %v, %v, want s3cret", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("key loaded %d times, want 2", calls)
+	}
+}
+
+func TestAESGCMUnboundDecryptKey(t *testing.T) {
+	a := testCipher(1)
+	unbound, err := a.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := a.DecryptKey("any.key", unbound); err != nil || got != "s3cret" {
+		t.Errorf("DecryptKey() = %v, %v, want values which are not bound to decrypt under any key", got, err)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Envelope
+		wantErr bool
+	}{
+		{value: "ENC[AES256_GCM,data:ZA==,iv:aXY=,type:int]", want: Envelope{Cipher: AES256GCM, Data: "ZA==", IV: "aXY=", Type: TypeInt}},
+		{value: "ENC[AES256_GCM,data:ZA==,iv:aXY=,type:str,aad:key]", want: Envelope{Cipher: AES256GCM, Data: "ZA==", IV: "aXY=", Type: TypeString, BoundKey: true}},
+		{value: "ENC[AES256_GCM,data]", wantErr: true},
+		{value: "ENC[AES256_GCM,aad:path]", wantErr: true},
+		{value: "plain", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			e, err := ParseEnvelope(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEnvelope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *e != tt.want {
+				t.Errorf("ParseEnvelope() = %+v, want %+v", *e, tt.want)
+			}
+			if e.String() != tt.value {
+				t.Errorf("String() = %q, want %q", e.String(), tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package crypt
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decrypter decrypts encrypted values of the form `ENC[<cipher>,data:...,iv:...,type:...]`
+type Decrypter interface {
+	// Decrypt decrypts the encrypted value into its plain value, a string, int64, float64 or bool
+	Decrypt(value string) (any, error)
+}
+
+// Encrypter encrypts plain values into encrypted values which its Decrypter decrypts
+type Encrypter interface {
+	// Encrypt encrypts the plain value, a string, integer, float or bool
+	Encrypt(value any) (string, error)
+}
+
+// KeyDecrypter is implemented by decrypters of values bound to their key path, see KeyEncrypter
+type KeyDecrypter interface {
+	// DecryptKey decrypts the encrypted value of the key path
+	DecryptKey(key string, value string) (any, error)
+}
+
+// KeyEncrypter is implemented by encrypters which bind encrypted values to their key path,
+// so a value copied to another key of the document fails to decrypt
+type KeyEncrypter interface {
+	// EncryptKey encrypts the plain value of the key path
+	EncryptKey(key string, value any) (string, error)
+}
+
+// Envelope the parts of an encrypted value `ENC[AES256_GCM,data:...,iv:...,type:str,aad:key]`,
+// data and iv are base64 encoded, type is the type of the plain value and `aad:key` marks
+// values bound to their key path
+type Envelope struct {
+	Cipher   string
+	Data     string
+	IV       string
+	Type     string
+	BoundKey bool
+}
+
+const (
+	envelopePrefix = "ENC["
+	envelopeSuffix = "]"
+)
+
+// value types of the plain values
+const (
+	TypeString = "str"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+)
+
+// IsEncrypted reports whether the value looks like an encrypted value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix) && strings.HasSuffix(value, envelopeSuffix)
+}
+
+// ParseEnvelope parses the encrypted value
+func ParseEnvelope(value string) (*Envelope, error) {
+	if !IsEncrypted(value) {
+		return nil, errors.New("value is not encrypted")
+	}
+	parts := strings.Split(value[len(envelopePrefix):len(value)-len(envelopeSuffix)], ",")
+	e := &Envelope{Cipher: parts[0], Type: TypeString}
+	for _, part := range parts[1:] {
+		k, v, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, errors.New("encrypted value part `" + part + "` is invalid")
+		}
+		switch k {
+		case "data":
+			e.Data = v
+		case "iv":
+			e.IV = v
+		case "type":
+			e.Type = v
+		case "aad":
+			if v != "key" {
+				return nil, errors.New("encrypted value aad `" + v + "` is unknown")
+			}
+			e.BoundKey = true
+		default:
+			return nil, errors.New("encrypted value part `" + k + "` is unknown")
+		}
+	}
+	if e.Cipher == "" || e.Data == "" || e.IV == "" {
+		return nil, errors.New("encrypted value is incomplete")
+	}
+	return e, nil
+}
+
+// String returns the encrypted value
+func (e *Envelope) String() string {
+	s := envelopePrefix + e.Cipher + ",data:" + e.Data + ",iv:" + e.IV + ",type:" + e.Type
+	if e.BoundKey {
+		s += ",aad:key"
+	}
+	return s + envelopeSuffix
+}
+
+// marshal returns the plain value as text and its type
+func marshal(value any) (string, string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, TypeString, nil
+	case bool:
+		return strconv.FormatBool(v), TypeBool, nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), TypeInt, nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), TypeInt, nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), TypeInt, nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), TypeInt, nil
+	case int64:
+		return strconv.FormatInt(v, 10), TypeInt, nil
+	case uint:
+		return marshalUint(uint64(v))
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), TypeInt, nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), TypeInt, nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), TypeInt, nil
+	case uint64:
+		return marshalUint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), TypeFloat, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), TypeFloat, nil
+	default:
+		return "", "", errors.New("only strings, numbers and bools can be encrypted")
+	}
+}
+
+// marshalUint returns the text of the unsigned integer, integers are decrypted as int64 so
+// values above math.MaxInt64 are rejected
+func marshalUint(v uint64) (string, string, error) {
+	if v > math.MaxInt64 {
+		return "", "", errors.New("integer " + strconv.FormatUint(v, 10) + " overflows int64 and can not be encrypted")
+	}
+	return strconv.FormatUint(v, 10), TypeInt, nil
+}
+
+// unmarshal returns the plain value of the text and type
+func unmarshal(text string, t string) (any, error) {
+	switch t {
+	case TypeString:
+		return text, nil
+	case TypeBool:
+		return strconv.ParseBool(text)
+	case TypeInt:
+		return strconv.ParseInt(text, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(text, 64)
+	default:
+		return nil, errors.New("encrypted value type `" + t + "` is unknown")
+	}
+}
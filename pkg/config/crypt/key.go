@@ -0,0 +1,91 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+)
+
+// KeySize the size of AES-256 keys
+const KeySize = 32
+
+// KeyProvider provides the key of a cipher
+type KeyProvider interface {
+	// Key returns the key
+	Key() ([]byte, error)
+}
+
+// KeyProviderFunc adapts a function to a KeyProvider
+type KeyProviderFunc func() ([]byte, error)
+
+// Key returns the key
+func (f KeyProviderFunc) Key() ([]byte, error) {
+	return f()
+}
+
+// StaticKey provides the raw key
+func StaticKey(key []byte) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		if len(key) != KeySize {
+			return nil, errors.New("key must be 32 bytes")
+		}
+		return key, nil
+	})
+}
+
+// FileKey provides the key read from the file, the file holds the raw key or its base64 or hex encoding
+func FileKey(path string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(b)
+		if err != nil {
+			return nil, errors.New("key file `" + path + "`: " + err.Error())
+		}
+		return key, nil
+	})
+}
+
+// EnvKey provides the key read from the environment variable, encoded in base64 or hex
+func EnvKey(name string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return nil, errors.New("key environment variable `" + name + "` is not set")
+		}
+		key, err := ParseKey([]byte(v))
+		if err != nil {
+			return nil, errors.New("key environment variable `" + name + "`: " + err.Error())
+		}
+		return key, nil
+	})
+}
+
+// ParseKey parses a raw, base64 or hex encoded key
+func ParseKey(b []byte) ([]byte, error) {
+	if len(b) == KeySize {
+		return b, nil
+	}
+	text := string(bytes.TrimSpace(b))
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, errors.New("key must be 32 bytes, raw or encoded in base64 or hex")
+}
+
+// GenerateKey generates a random key encoded in base64
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io"
 	"sync"
 	"time"
 
@@ -136,3 +137,121 @@ func Get(key string) (any, error) {
 func Set(key string, value interface{}) error {
 	return L().Set(key, value)
 }
+
+// Update applies the changes staged by fn atomically and persists them once
+func Update(fn func(tx *Tx) error) error {
+	u, err := updatable()
+	if err != nil {
+		return err
+	}
+	return u.Update(fn)
+}
+
+// SetInMemory sets a runtime override of the key which is never written to disk
+func SetInMemory(key string, value any) error {
+	u, err := updatable()
+	if err != nil {
+		return err
+	}
+	return u.SetInMemory(key, value)
+}
+
+// Delete removes the key
+func Delete(key string) error {
+	m, err := keyManager()
+	if err != nil {
+		return err
+	}
+	return m.Delete(key)
+}
+
+// Rename moves the value of oldKey to newKey
+func Rename(oldKey, newKey string) error {
+	m, err := keyManager()
+	if err != nil {
+		return err
+	}
+	return m.Rename(oldKey, newKey)
+}
+
+// Keys returns the flattened dotted keys under the given prefix
+func Keys(prefix string) []string {
+	m, err := keyManager()
+	if err != nil {
+		return nil
+	}
+	return m.Keys(prefix)
+}
+
+// AllKeys returns all flattened dotted keys
+func AllKeys() []string {
+	m, err := keyManager()
+	if err != nil {
+		return nil
+	}
+	return m.AllKeys()
+}
+
+// Walk walks the configuration tree in key order
+func Walk(fn WalkFunc) error {
+	m, err := keyManager()
+	if err != nil {
+		return err
+	}
+	return m.Walk(fn)
+}
+
+// AllSettings returns the whole configuration as plain values
+func AllSettings() map[string]any {
+	m, err := keyManager()
+	if err != nil {
+		return nil
+	}
+	return m.AllSettings()
+}
+
+// EncryptKeys encrypts the values of the keys in place and saves the configuration once
+func EncryptKeys(keys ...string) error {
+	e, err := encryptable()
+	if err != nil {
+		return err
+	}
+	return e.EncryptKeys(keys...)
+}
+
+// Dump writes the effective configuration in the format with sensitive keys masked
+func Dump(w io.Writer, format string, opts ...DumpOption) error {
+	d, err := dumper()
+	if err != nil {
+		return err
+	}
+	return d.Dump(w, format, opts...)
+}
+
+// GetValue returns the value of the key wrapped in Value, which masks sensitive keys when formatted
+func GetValue(key string) (Value, error) {
+	d, err := dumper()
+	if err != nil {
+		return Value{}, err
+	}
+	return d.GetValue(key)
+}
+
+// Watch watches the key paths of the default config, every key if none is given
+func Watch(paths ...string) error {
+	w, err := watchable()
+	if err != nil {
+		return err
+	}
+	return w.Watch(paths...)
+}
+
+// OnChange registers the handler of the changes of the watched keys of the default config
+func OnChange(handler func(changes []Change)) error {
+	w, err := watchable()
+	if err != nil {
+		return err
+	}
+	w.OnChange(handler)
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"reflect"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// defaultsLoader decodes a layer of defaults
+type defaultsLoader func() (map[string]configer.Field, error)
+
+// WithDefaults adds defaults decoded from the given bytes, e.g. a `//go:embed defaults.toml` file.
+// Keys missing in the configuration file fall back to the defaults.
+func WithDefaults(b []byte, decoder configer.Decoder) Option {
+	return defaultsOption(func() (map[string]configer.Field, error) {
+		if decoder == nil {
+			return nil, errors.New("defaults decoder is nil")
+		}
+		return decoder.Decode(b)
+	})
+}
+
+// WithDefaultsFS adds defaults read from the named file of fsys, the codec is chosen by the file ext
+func WithDefaultsFS(fsys fs.FS, name string) Option {
+	return defaultsOption(func() (map[string]configer.Field, error) {
+		_, codec, ok := encoding.ByExtension(path.Ext(name))
+		if !ok {
+			return nil, errors.New("defaults file `" + name + "` ext not support")
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Decode(b)
+	})
+}
+
+type defaultsOption defaultsLoader
+
+func (o defaultsOption) apply(opts *options) {
+	opts.defaults = append(opts.defaults, defaultsLoader(o))
+}
+
+// loadDefaults decodes and merges the defaults layers, later layers take precedence
+func loadDefaults(loaders []defaultsLoader) (map[string]configer.Field, error) {
+	defaults := make(map[string]configer.Field)
+	for _, load := range loaders {
+		layer, err := load()
+		if err != nil {
+			return nil, err
+		}
+		field.MergeMap(defaults, layer)
+	}
+	return defaults, nil
+}
+
+// diffDefaults returns the entries of configMap which differ from the defaults
+func diffDefaults(configMap, defaults map[string]configer.Field) map[string]configer.Field {
+	diff := make(map[string]configer.Field, len(configMap))
+	for key, value := range configMap {
+		def, ok := defaults[key]
+		if !ok {
+			diff[key] = value
+			continue
+		}
+		subMap, isSection := value.Value.(map[string]configer.Field)
+		defMap, defIsSection := def.Value.(map[string]configer.Field)
+		if isSection && defIsSection {
+			if sub := diffDefaults(subMap, defMap); len(sub) > 0 {
+				diff[key] = configer.Field{Type: configer.FieldTypeSection, Value: sub}
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, def) {
+			diff[key] = value
+		}
+	}
+	return diff
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+)
+
+const defaultsDocument = `
+[server]
+host = "0.0.0.0"
+port = 80
+`
+
+func TestDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "bytes", opts: []Option{WithDefaults([]byte(defaultsDocument), encoding.DefaultCodec)}},
+		{name: "fs", opts: []Option{WithDefaultsFS(fstest.MapFS{"defaults.toml": {Data: []byte(defaultsDocument)}}, "defaults.toml")}},
+		{name: "layers", opts: []Option{
+			WithDefaults([]byte("[server]\nhost = \"127.0.0.1\"\nport = 1\n"), encoding.DefaultCodec),
+			WithDefaults([]byte(defaultsDocument), encoding.DefaultCodec),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.toml", "[server]\nport = 8080\n", tt.opts...)
+			if got, err := c.GetString("server.host"); err != nil || got != "0.0.0.0" {
+				t.Errorf("GetString(server.host) = %q, %v, want the default", got, err)
+			}
+			if got, err := c.GetInt64("server.port"); err != nil || got != 8080 {
+				t.Errorf("GetInt64(server.port) = %v, %v, want the file value", got, err)
+			}
+			if err := c.Set("server.debug", true); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(c.FilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(b), "host") {
+				t.Errorf("saved file = %q, want defaults left out", b)
+			}
+		})
+	}
+}
+
+func TestDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{name: "nil decoder", opt: WithDefaults([]byte(defaultsDocument), nil)},
+		{name: "unknown ext", opt: WithDefaultsFS(fstest.MapFS{"defaults.unknown": {}}, "defaults.unknown")},
+		{name: "missing file", opt: WithDefaultsFS(fstest.MapFS{}, "defaults.toml")},
+		{name: "invalid document", opt: WithDefaults([]byte("[server"), encoding.DefaultCodec)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &options{}
+			tt.opt.apply(opts)
+			if _, err := loadDefaults(opts.defaults); err == nil {
+				t.Error("loadDefaults() succeeded, want error")
+			}
+		})
+	}
+}
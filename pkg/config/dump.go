@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+const (
+	// SourceDefaults the source of values from the embedded defaults
+	SourceDefaults = "defaults"
+	// SourceMemory the source of runtime overrides
+	SourceMemory = "memory"
+	// FormatText the dump format listing one `key = value # source` line per key
+	FormatText = "text"
+)
+
+// Dumper is implemented by configurations which can dump their effective settings with sensitive keys masked
+type Dumper interface {
+	// Dump writes the effective configuration in the format, FormatText or the name of a registered codec
+	Dump(w io.Writer, format string, opts ...DumpOption) error
+	// GetValue returns the value of the key wrapped in Value, which masks sensitive keys when formatted
+	GetValue(key string) (Value, error)
+}
+
+var _ Dumper = (*config)(nil)
+
+// Dump writes the effective configuration, values of sensitive keys are masked. The text format lists
+// every key with its value and source, codecs encode every value as a section of its `value` and
+// `source` unless WithoutSources is given.
+func (c *config) Dump(w io.Writer, format string, opts ...DumpOption) error {
+	options := &dumpOptions{sources: true}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	var codec configer.Codec
+	if format != "" && format != FormatText {
+		var ok bool
+		if codec, ok = encoding.Lookup(format); !ok {
+			return errors.New("dump format `" + format + "` not support")
+		}
+	}
+	c.RLock()
+	var values []Value
+	_ = walkRecursive(c.settings(), nil, func(key string, f configer.Field) error {
+		if f.Type == configer.FieldTypeSection {
+			return nil
+		}
+		path, err := ParsePath(key)
+		if err != nil {
+			return err
+		}
+		values = append(values, newValue(path, f, c.sourceOf(path)))
+		return nil
+	})
+	c.RUnlock()
+	if codec == nil {
+		for _, v := range values {
+			line := v.Key + " = " + formatDumpValue(v.masked)
+			if options.sources {
+				line += " # " + v.Source
+			}
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	configMap := make(map[string]configer.Field)
+	for _, v := range values {
+		leaf := configer.Atof(v.masked)
+		if options.sources {
+			leaf = configer.Atof(map[string]any{"value": v.masked, "source": v.Source})
+		}
+		if err := setPath(configMap, MustParsePath(v.Key), leaf); err != nil {
+			return err
+		}
+	}
+	b, err := codec.Encode(configMap)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (c *config) GetValue(key string) (Value, error) {
+	path, err := ParsePath(key)
+	if err != nil {
+		return Value{}, err
+	}
+	f, err := c.getPath(path, key)
+	if err != nil {
+		return Value{}, err
+	}
+	c.RLock()
+	defer c.RUnlock()
+	v := newValue(path, f, c.sourceOf(path))
+	// decrypted values are masked whatever their key
+	if raw, _, ok := c.find(path); ok {
+		if s, ok := raw.Value.(string); ok && crypt.IsEncrypted(s) {
+			v.masked = secret.Redacted
+		}
+	}
+	return v, nil
+}
+
+// sourceOf returns the layer the value of the path comes from, the caller must hold the lock
+func (c *config) sourceOf(path Path) string {
+	if resolved, ok := resolveAlias(path); ok {
+		path = resolved
+	}
+	if _, ok := findPath(c.overrides, path, c.normalizeKeys); ok {
+		return SourceMemory
+	}
+	if _, ok := findPath(c.configMap, path, c.normalizeKeys); ok {
+		switch {
+		case c.FilePath != "":
+			return c.FilePath
+		case c.SourceURL != nil:
+			return c.SourceURL.String()
+		default:
+			return "config"
+		}
+	}
+	if _, ok := findPath(c.defaults, path, c.normalizeKeys); ok {
+		return SourceDefaults
+	}
+	return ""
+}
+
+// formatDumpValue formats the value of the text format, strings are quoted and arrays are written as JSON
+func formatDumpValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]any, []any:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func dumper() (Dumper, error) {
+	d, ok := L().(Dumper)
+	if !ok {
+		return nil, errors.New("default config does not support dumping")
+	}
+	return d, nil
+}
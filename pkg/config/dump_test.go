@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+)
+
+// newDumpConfig loads a file over defaults with a runtime override
+func newDumpConfig(t *testing.T, opts ...Option) *config {
+	t.Helper()
+	codec, ok := encoding.Lookup("toml")
+	if !ok {
+		t.Fatal("toml codec not registered")
+	}
+	opts = append([]Option{WithDefaults([]byte("timeout = 30\n[db]\nhost = \"localhost\"\n"), codec)}, opts...)
+	c := newTestConfig(t, "config.toml", "name = \"app\"\ntags = [\"a\", \"b\"]\n[db]\nhost = \"db.local\"\npassword = \"s3cret\"\n", opts...)
+	if err := c.SetInMemory("db.port", int64(5432)); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDump(t *testing.T) {
+	c := newDumpConfig(t)
+	source := c.FilePath
+	tests := []struct {
+		name    string
+		format  string
+		opts    []DumpOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			want: `db.host = "db.local" # ` + source + "\n" +
+				`db.password = "` + secret.Redacted + `" # ` + source + "\n" +
+				"db.port = 5432 # " + SourceMemory + "\n" +
+				`name = "app" # ` + source + "\n" +
+				`tags = ["a","b"] # ` + source + "\n" +
+				"timeout = 30 # " + SourceDefaults + "\n",
+		},
+		{
+			name:   "text without sources",
+			format: "",
+			opts:   []DumpOption{WithoutSources()},
+			want:   "db.host = \"db.local\"\ndb.password = \"" + secret.Redacted + "\"\ndb.port = 5432\nname = \"app\"\ntags = [\"a\",\"b\"]\ntimeout = 30\n",
+		},
+		{name: "unknown format", format: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := c.Dump(&b, tt.format, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Dump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if b.String() != tt.want {
+				t.Errorf("Dump() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDumpCodec(t *testing.T) {
+	c := newDumpConfig(t)
+	tests := []struct {
+		name string
+		opts []DumpOption
+		want map[string]any
+	}{
+		{
+			name: "with sources",
+			want: map[string]any{
+				"db": map[string]any{
+					"host":     map[string]any{"value": "db.local", "source": c.FilePath},
+					"password": map[string]any{"value": secret.Redacted, "source": c.FilePath},
+					"port":     map[string]any{"value": float64(5432), "source": SourceMemory},
+				},
+				"name":    map[string]any{"value": "app", "source": c.FilePath},
+				"tags":    map[string]any{"value": []any{"a", "b"}, "source": c.FilePath},
+				"timeout": map[string]any{"value": float64(30), "source": SourceDefaults},
+			},
+		},
+		{
+			name: "without sources",
+			opts: []DumpOption{WithoutSources()},
+			want: map[string]any{
+				"db":      map[string]any{"host": "db.local", "password": secret.Redacted, "port": float64(5432)},
+				"name":    "app",
+				"tags":    []any{"a", "b"},
+				"timeout": float64(30),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := c.Dump(&b, "json", tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+				t.Fatalf("Dump() = %s, not JSON: %v", b.String(), err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Dump() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetValue(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	c := newDumpConfig(t, WithDecrypter(a))
+	if err := c.SetInMemory("db.user", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EncryptKeys("name"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key        string
+		wantSource string
+		wantValue  any
+		wantString string
+		wantJSON   string
+	}{
+		{key: "db.host", wantSource: c.FilePath, wantValue: "db.local", wantString: "db.local", wantJSON: `"db.local"`},
+		{key: "db.password", wantSource: c.FilePath, wantValue: "s3cret", wantString: secret.Redacted, wantJSON: `"` + secret.Redacted + `"`},
+		{key: "db.port", wantSource: SourceMemory, wantValue: int64(5432), wantString: "5432", wantJSON: "5432"},
+		{key: "timeout", wantSource: SourceDefaults, wantValue: int64(30), wantString: "30", wantJSON: "30"},
+		{key: "name", wantSource: c.FilePath, wantValue: "app", wantString: secret.Redacted, wantJSON: `"` + secret.Redacted + `"`},
+		{key: "db", wantSource: SourceMemory, wantValue: map[string]any{"host": "db.local", "password": "s3cret", "port": int64(5432), "user": "admin"},
+			wantString: "map[host:db.local password:" + secret.Redacted + " port:5432 user:admin]",
+			wantJSON:   `{"host":"db.local","password":"` + secret.Redacted + `","port":5432,"user":"admin"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v, err := c.GetValue(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Key != tt.key || v.Source != tt.wantSource {
+				t.Errorf("GetValue() key, source = %q, %q, want %q, %q", v.Key, v.Source, tt.key, tt.wantSource)
+			}
+			if got := v.Interface(); !reflect.DeepEqual(got, tt.wantValue) {
+				t.Errorf("Interface() = %#v, want %#v", got, tt.wantValue)
+			}
+			if got := fmt.Sprint(v); got != tt.wantString {
+				t.Errorf("Sprint() = %q, want %q", got, tt.wantString)
+			}
+			if got := fmt.Sprintf("%v", v); got != tt.wantString {
+				t.Errorf("Sprintf(%%v) = %q, want %q", got, tt.wantString)
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.wantJSON {
+				t.Errorf("MarshalJSON() = %s, want %s", b, tt.wantJSON)
+			}
+		})
+	}
+	if _, err := c.GetValue("missing"); err == nil {
+		t.Error("GetValue() of a missing key succeeded")
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	type credentials struct {
+		Login  string `config:"login"`
+		Pin    string `config:"pin,sensitive"`
+		Nested struct {
+			Cert string `config:"cert,sensitive"`
+		} `config:"nested"`
+	}
+	if err := MarkSensitive("dumptest.*.dsn", "*fingerprint"); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarkSensitiveStruct("dumptest.creds", credentials{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarkSensitive("["); err == nil {
+		t.Error("MarkSensitive() of an invalid pattern succeeded")
+	}
+	if err := MarkSensitiveStruct("", "not a struct"); err == nil {
+		t.Error("MarkSensitiveStruct() of a string succeeded")
+	}
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "db.password", want: true},
+		{key: "db.PASSWORD", want: true},
+		{key: "auth.api_key", want: true},
+		{key: "servers[0].token", want: true},
+		{key: "db.host", want: false},
+		{key: "dumptest.primary.dsn", want: true},
+		{key: "dumptest.dsn", want: false},
+		{key: "device.fingerprint", want: true},
+		{key: "dumptest.creds.pin", want: true},
+		{key: "dumptest.creds.login", want: false},
+		{key: "dumptest.creds.nested.cert", want: true},
+		{key: "other.pin", want: false},
+		{key: "[", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := IsSensitive(tt.key); got != tt.want {
+				t.Errorf("IsSensitive(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+	if !strings.Contains(fmt.Sprint(mask(MustParsePath("dumptest"), map[string]any{"creds": map[string]any{"pin": "1234"}})), secret.Redacted) {
+		t.Error("mask() does not mask nested sensitive keys")
+	}
+}
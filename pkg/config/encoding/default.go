@@ -1,8 +1,12 @@
 package encoding
 
 import (
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/env"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/hcl"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/ini"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/json"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/properties"
 	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/toml"
-	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/yml"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 )
 
@@ -22,12 +26,65 @@ func (c *tomlCodec) Encode(m map[string]configer.Field) ([]byte, error) {
 	return toml.Encode(m)
 }
 
-type ymlCodec struct{}
+// EncodePreserving the impl of PreservingEncoder
+func (c *tomlCodec) EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	return toml.EncodePreserving(original, m)
+}
+
+type envCodec struct{}
+
+func (c *envCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return env.Decode(b)
+}
+
+func (c *envCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return env.Encode(m)
+}
+
+type propertiesCodec struct{}
+
+func (c *propertiesCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return properties.Decode(b)
+}
+
+func (c *propertiesCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return properties.Encode(m)
+}
+
+func (c *propertiesCodec) EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	return properties.EncodePreserving(original, m)
+}
+
+type iniCodec struct{}
+
+func (c *iniCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return ini.Decode(b)
+}
+
+func (c *iniCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return ini.Encode(m)
+}
+
+func (c *iniCodec) EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	return ini.EncodePreserving(original, m)
+}
+
+type hclCodec struct{}
+
+func (c *hclCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return hcl.Decode(b)
+}
+
+func (c *hclCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return hcl.Encode(m)
+}
+
+type jsonCodec struct{}
 
-func (c *ymlCodec) Decode(b []byte) (map[string]configer.Field, error) {
-	return yml.Decode(b)
+func (c *jsonCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return json.Decode(b)
 }
 
-func (c *ymlCodec) Encode(m map[string]configer.Field) ([]byte, error) {
-	return yml.Encode(m)
+func (c *jsonCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return json.Encode(m)
 }
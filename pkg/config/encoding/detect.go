@@ -0,0 +1,97 @@
+package encoding
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"sync"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/toml"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/yml"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Auto the codec name which detects the format from the content
+const Auto EncType = "auto"
+
+// ForContentType returns the codec type of the Content-Type, structured syntax suffixes
+// such as `+json` are recognized
+func ForContentType(contentType string) (EncType, bool) {
+	name, _, ok := DefaultRegistry.ByMIME(contentType)
+	return EncType(name), ok
+}
+
+// Detect sniffs the format of the content: JSON, XML, TOML, then YAML
+func Detect(b []byte) (EncType, bool) {
+	content := bytes.TrimSpace(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")))
+	switch {
+	case len(content) == 0:
+		return "", false
+	case content[0] == '{' && stdjson.Valid(content):
+		return Json, true
+	case content[0] == '<':
+		return Xml, true
+	}
+	if _, err := toml.Decode(content); err == nil {
+		return Toml, true
+	}
+	if _, err := yml.Decode(content); err == nil {
+		return Yml, true
+	}
+	return "", false
+}
+
+// autoCodec detects the format on Decode and encodes in the detected format, TOML before
+type autoCodec struct {
+	mu       sync.RWMutex
+	detected configer.Codec
+}
+
+// NewAutoCodec new codec which detects the format of the content on every Decode,
+// a codec is needed per configuration as it remembers the detected format for encoding
+func NewAutoCodec() configer.Codec {
+	return &autoCodec{}
+}
+
+func (c *autoCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	t, ok := Detect(b)
+	if !ok {
+		if len(bytes.TrimSpace(b)) == 0 {
+			return make(map[string]configer.Field), nil
+		}
+		return nil, errNotDetected
+	}
+	codec := GetSupport(t.String())
+	if codec == nil {
+		return nil, errNotDetected
+	}
+	m, err := codec.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.detected = codec
+	c.mu.Unlock()
+	return m, nil
+}
+
+func (c *autoCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return c.codec().Encode(m)
+}
+
+func (c *autoCodec) EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	codec := c.codec()
+	if preserving, ok := codec.(PreservingEncoder); ok {
+		return preserving.EncodePreserving(original, m)
+	}
+	return codec.Encode(m)
+}
+
+// codec returns the detected codec, TOML if nothing has been decoded yet
+func (c *autoCodec) codec() configer.Codec {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.detected == nil {
+		return DefaultCodec
+	}
+	return c.detected
+}
@@ -0,0 +1,90 @@
+package encoding
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   EncType
+		wantOK bool
+	}{
+		{name: "json", src: `{"a": 1}`, want: Json, wantOK: true},
+		{name: "json with bom", src: "\xef\xbb\xbf  {\"a\": 1}\n", want: Json, wantOK: true},
+		{name: "xml", src: "<?xml version=\"1.0\"?><a/>", want: Xml, wantOK: true},
+		{name: "toml", src: "[server]\nport = 8080\n", want: Toml, wantOK: true},
+		{name: "yaml", src: "server:\n  port: 8080\n", want: Yml, wantOK: true},
+		{name: "yaml flow mapping", src: "{a: 1}", want: Yml, wantOK: true},
+		{name: "empty", src: " \n", wantOK: false},
+		{name: "garbage", src: "= [", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Detect([]byte(tt.src))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Detect() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        EncType
+		wantOK      bool
+	}{
+		{contentType: "application/json", want: Json, wantOK: true},
+		{contentType: "application/json; charset=utf-8", want: Json, wantOK: true},
+		{contentType: "application/vnd.api+json", want: Json, wantOK: true},
+		{contentType: "Application/YAML", want: Yml, wantOK: true},
+		{contentType: "application/toml", want: Toml, wantOK: true},
+		{contentType: "application/soap+xml", want: Xml, wantOK: true},
+		{contentType: "text/plain", wantOK: false},
+		{contentType: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, ok := ForContentType(tt.contentType)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ForContentType() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAutoCodec(t *testing.T) {
+	codec := NewAutoCodec()
+	if _, err := codec.Decode([]byte("= [")); err == nil {
+		t.Error("Decode() of undetected content succeeded")
+	}
+	m, err := codec.Decode([]byte("server:\n  port: 8080\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := field.ToMap(m)["server"].(map[string]any)["port"]; got != 8080 {
+		t.Errorf("server.port = %v, want 8080", got)
+	}
+	b, err := codec.Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "server:\n") {
+		t.Errorf("Encode() = %q, want YAML, the detected format", b)
+	}
+	if m, err := NewAutoCodec().Decode(nil); err != nil || len(m) != 0 {
+		t.Errorf("Decode() of empty content = %v, %v, want an empty map", m, err)
+	}
+	b, err = NewAutoCodec().Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "[server]") {
+		t.Errorf("Encode() before Decode = %q, want TOML", b)
+	}
+}
@@ -0,0 +1,318 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Decode decodes the given dotenv bytes to the config map.
+//
+// Lines are `KEY=VALUE` with an optional `export` prefix, `#` starts a comment.
+// Double quoted values support escapes and may span lines, single quoted values are literal.
+// `${VAR}`, `${VAR:-default}` and `$VAR` are expanded in unquoted and double quoted values from
+// the keys defined above, then the process environment. Unquoted values are parsed into
+// integers, floats and booleans. `__` and `.` in keys create nested sections.
+func Decode(b []byte) (map[string]configer.Field, error) {
+	p := &parser{src: []rune(string(b)), line: 1, vars: make(map[string]string)}
+	configMap := make(map[string]configer.Field)
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return configMap, nil
+		}
+		levels := strings.Split(strings.ReplaceAll(key, "__", "."), ".")
+		if err := field.SetLevels(configMap, levels, configer.Atof(value)); err != nil {
+			return nil, errors.New("env: line " + strconv.Itoa(p.line) + ": " + err.Error())
+		}
+	}
+}
+
+// Encode encodes the given config map to dotenv bytes, nested keys are joined with `__`
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	var lines []string
+	if err := encode(m, "", &lines); err != nil {
+		return nil, err
+	}
+	sort.Strings(lines)
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func encode(m map[string]configer.Field, prefix string, lines *[]string) error {
+	for key, f := range m {
+		if strings.Contains(key, "__") || strings.Contains(key, ".") || strings.ContainsAny(key, "= \t\n#") {
+			return errors.New("env: key `" + key + "` cannot be encoded")
+		}
+		name := prefix + key
+		if sub, ok := f.Value.(map[string]configer.Field); ok && f.Type == configer.FieldTypeSection {
+			if err := encode(sub, name+"__", lines); err != nil {
+				return err
+			}
+			continue
+		}
+		*lines = append(*lines, name+"="+formatValue(f.Value))
+	}
+	return nil
+}
+
+// formatValue formats numbers and booleans bare and quotes strings which would not decode to themselves
+func formatValue(v any) string {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(v)
+	}
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	if s != "" && s == strings.TrimSpace(s) && !strings.ContainsAny(s, "\"'\\$#\n\r\t") {
+		if _, typed := field.ParseScalar(s).(string); typed {
+			return s
+		}
+	}
+	return quote(s)
+}
+
+func quote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "$", "\\$", "\n", "\\n", "\r", "\\r", "\t", "\\t")
+	return "\"" + r.Replace(s) + "\""
+}
+
+type parser struct {
+	src  []rune
+	pos  int
+	line int
+	vars map[string]string
+}
+
+// next returns the next assignment, ok is false at the end of the input
+func (p *parser) next() (key string, value any, ok bool, err error) {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return "", nil, false, nil
+		}
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+		break
+	}
+	key = p.readKey()
+	if key == "export" {
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] != '=' {
+			key = p.readKey()
+		}
+	}
+	if key == "" {
+		return "", nil, false, p.errorf("key expected")
+	}
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+		return "", nil, false, p.errorf("`=` expected after `" + key + "`")
+	}
+	p.pos++
+	p.skipSpace()
+	var raw string
+	if p.pos < len(p.src) && (p.src[p.pos] == '"' || p.src[p.pos] == '\'') {
+		if raw, err = p.readQuoted(); err != nil {
+			return "", nil, false, err
+		}
+		value = raw
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '#' {
+			p.skipLine()
+		}
+		if p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			return "", nil, false, p.errorf("unexpected characters after quoted value of `" + key + "`")
+		}
+	} else {
+		raw = p.expand(p.readUnquoted())
+		value = field.ParseScalar(raw)
+	}
+	p.vars[key] = raw
+	return key, value, true, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *parser) readKey() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if r == '=' || r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == '#' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// readUnquoted reads the value up to the end of the line or an inline ` #` comment
+func (p *parser) readUnquoted() string {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		if p.src[p.pos] == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			value := string(p.src[start:p.pos])
+			p.skipLine()
+			return strings.TrimSpace(value)
+		}
+		p.pos++
+	}
+	return strings.TrimSpace(string(p.src[start:p.pos]))
+}
+
+// readQuoted reads a single or double quoted value, which may span lines. Escapes and
+// variables of double quoted values are handled in one pass, so `\\$VAR` is a backslash
+// followed by the value of VAR and `\$VAR` is literal.
+func (p *parser) readQuoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		p.pos++
+		switch {
+		case r == quote:
+			return sb.String(), nil
+		case r == '\n':
+			p.line++
+			sb.WriteRune(r)
+		case r == '$' && quote == '"':
+			value, next, ok := p.variable(p.src, p.pos-1, quote)
+			if !ok {
+				sb.WriteRune(r)
+				continue
+			}
+			sb.WriteString(value)
+			p.pos = next
+		case r == '\\' && quote == '"' && p.pos < len(p.src):
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 'r':
+				sb.WriteRune('\r')
+			case 't':
+				sb.WriteRune('\t')
+			case '"', '\\', '$':
+				sb.WriteRune(e)
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(e)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorf("unterminated quoted value")
+}
+
+// expand expands `${VAR}`, `${VAR:-default}` and `$VAR` of an unquoted value, `\$` is a literal dollar sign
+func (p *parser) expand(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	src := []rune(s)
+	var sb strings.Builder
+	for i := 0; i < len(src); i++ {
+		switch {
+		case src[i] == '\\' && i+1 < len(src) && src[i+1] == '$':
+			sb.WriteRune('$')
+			i++
+		case src[i] == '$':
+			value, next, ok := p.variable(src, i, 0)
+			if !ok {
+				sb.WriteRune('$')
+				continue
+			}
+			sb.WriteString(value)
+			i = next - 1
+		default:
+			sb.WriteRune(src[i])
+		}
+	}
+	return sb.String()
+}
+
+// variable expands the reference starting with the `$` at src[i] and returns the index after it,
+// ok is false if there is no reference. A `${` reference must be closed before the stop rune.
+func (p *parser) variable(src []rune, i int, stop rune) (value string, next int, ok bool) {
+	if i+1 >= len(src) {
+		return "", 0, false
+	}
+	if src[i+1] == '{' {
+		end := i + 2
+		for end < len(src) && src[end] != '}' {
+			if src[end] == stop || src[end] == '\n' {
+				return "", 0, false
+			}
+			end++
+		}
+		if end >= len(src) {
+			return "", 0, false
+		}
+		name, fallback, hasDefault := strings.Cut(string(src[i+2:end]), ":-")
+		value, ok := p.lookup(name)
+		if (!ok || value == "") && hasDefault {
+			value = fallback
+		}
+		return value, end + 1, true
+	}
+	j := i + 1
+	for j < len(src) && src[j] < 0x80 && isNameByte(byte(src[j])) {
+		j++
+	}
+	if j == i+1 {
+		return "", 0, false
+	}
+	value, _ = p.lookup(string(src[i+1 : j]))
+	return value, j, true
+}
+
+func (p *parser) lookup(name string) (string, bool) {
+	if value, ok := p.vars[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+func (p *parser) errorf(msg string) error {
+	return errors.New("env: line " + strconv.Itoa(p.line) + ": " + msg)
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
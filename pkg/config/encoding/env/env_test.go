@@ -0,0 +1,78 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+)
+
+func TestDecode(t *testing.T) {
+	t.Setenv("HOME", "/home/app")
+	t.Setenv("EMPTY", "")
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "typed", src: "PORT=8080\nRATIO=0.5\nDEBUG=true\nNAME=app\n", want: map[string]any{
+			"PORT": int64(8080), "RATIO": 0.5, "DEBUG": true, "NAME": "app",
+		}},
+		{name: "export and comments", src: "# comment\nexport NAME=app # inline\nURL=a#b\n", want: map[string]any{
+			"NAME": "app", "URL": "a#b",
+		}},
+		{name: "nested", src: "DB__HOST=localhost\ndb.port=5432\n", want: map[string]any{
+			"DB": map[string]any{"HOST": "localhost"}, "db": map[string]any{"port": int64(5432)},
+		}},
+		{name: "quoted strings stay strings", src: "PORT=\"8080\"\nRAW='a\\nb $HOME'\n", want: map[string]any{
+			"PORT": "8080", "RAW": "a\\nb $HOME",
+		}},
+		{name: "escapes", src: `V="a\tb\n\"c\""`, want: map[string]any{"V": "a\tb\n\"c\""}},
+		{name: "multiline", src: "V=\"a\nb\"\nW=1\n", want: map[string]any{"V": "a\nb", "W": int64(1)}},
+		{name: "expansion", src: "A=x\nB=${A}y\nC=\"$A/$HOME\"\nD=${MISSING:-def}\nE=${EMPTY:-def}\n", want: map[string]any{
+			"A": "x", "B": "xy", "C": "x//home/app", "D": "def", "E": "def",
+		}},
+		{name: "escaped dollar", src: `A="\$HOME"` + "\nB=\\$HOME\n", want: map[string]any{"A": "$HOME", "B": "$HOME"}},
+		{name: "escaped backslash before variable", src: `A="x\\$HOME"`, want: map[string]any{"A": "x\\/home/app"}},
+		{name: "escaped backslash before escaped dollar", src: `A="x\\\$HOME"`, want: map[string]any{"A": "x\\$HOME"}},
+		{name: "unterminated reference", src: `A="${HOME"` + "\nB=${HOME\n", want: map[string]any{"A": "${HOME", "B": "${HOME"}},
+		{name: "lone dollar", src: `A="5$"` + "\nB=$ 1\n", want: map[string]any{"A": "5$", "B": "$ 1"}},
+		{name: "unterminated quote", src: "A=\"x\n", wantErr: true},
+		{name: "missing equals", src: "A\n", wantErr: true},
+		{name: "trailing characters", src: "A=\"x\" y\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m := field.ToMap(got); !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	src := "A=\"x\\\\$HOME\"\nDB__HOST=localhost\nDB__PORT=5432\nN=\"8080\"\nS=\"a b\\n\"\n"
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %#v, want %#v\n%s", field.ToMap(back), field.ToMap(m), b)
+	}
+}
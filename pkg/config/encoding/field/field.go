@@ -0,0 +1,214 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Ftoa converts the given field back to its plain value, sections become `map[string]any`
+func Ftoa(f configer.Field) any {
+	if f.Type == configer.FieldTypeSection {
+		if subMap, ok := f.Value.(map[string]configer.Field); ok {
+			return ToMap(subMap)
+		}
+	}
+	return f.Value
+}
+
+// ToMap converts the given config map to a plain map which can be marshaled by codecs
+func ToMap(configMap map[string]configer.Field) map[string]any {
+	m := make(map[string]any, len(configMap))
+	for key, value := range configMap {
+		m[key] = Ftoa(value)
+	}
+	return m
+}
+
+// CopyMap returns a deep copy of the given config map, nested sections are copied as well
+func CopyMap(configMap map[string]configer.Field) map[string]configer.Field {
+	m := make(map[string]configer.Field, len(configMap))
+	for key, value := range configMap {
+		if subMap, ok := value.Value.(map[string]configer.Field); ok && value.Type == configer.FieldTypeSection {
+			value = configer.Field{Type: configer.FieldTypeSection, Value: CopyMap(subMap)}
+		}
+		m[key] = value
+	}
+	return m
+}
+
+// MergeMap merges src into dst recursively, values of src take precedence
+func MergeMap(dst, src map[string]configer.Field) {
+	for key, value := range src {
+		srcMap, srcOk := value.Value.(map[string]configer.Field)
+		dstMap, dstOk := dst[key].Value.(map[string]configer.Field)
+		if srcOk && dstOk && value.Type == configer.FieldTypeSection && dst[key].Type == configer.FieldTypeSection {
+			MergeMap(dstMap, srcMap)
+			continue
+		}
+		if srcOk && value.Type == configer.FieldTypeSection {
+			value = configer.Field{Type: configer.FieldTypeSection, Value: CopyMap(srcMap)}
+		}
+		dst[key] = value
+	}
+}
+
+// ParseScalar parses text of untyped formats into an integer, a decimal float or the booleans
+// `true` and `false`, or keeps the string. Text such as `NaN`, `Inf`, `0x1p-2`, `t` or `1` for true stays a string.
+func ParseScalar(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if !isDecimal(s) {
+		return s
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// isDecimal reports whether s is a decimal number with an optional sign, fraction and exponent
+func isDecimal(s string) bool {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	digits := 0
+	for len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+		s, digits = s[1:], digits+1
+	}
+	if len(s) > 0 && s[0] == '.' {
+		s = s[1:]
+		for len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+			s, digits = s[1:], digits+1
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	if len(s) > 0 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if len(s) == 0 {
+			return false
+		}
+		for len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+			s = s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// SetLevels sets the field at the nested keys, missing sections are created.
+// It fails if a level is empty or conflicts with an existing value.
+func SetLevels(configMap map[string]configer.Field, levels []string, f configer.Field) error {
+	for _, level := range levels {
+		if level == "" {
+			return errors.New("invalid key `" + strings.Join(levels, ".") + "`")
+		}
+	}
+	m := configMap
+	for i, level := range levels[:len(levels)-1] {
+		sub, ok := m[level]
+		if !ok {
+			subMap := make(map[string]configer.Field)
+			m[level] = configer.Field{Type: configer.FieldTypeSection, Value: subMap}
+			m = subMap
+			continue
+		}
+		subMap, ok := sub.Value.(map[string]configer.Field)
+		if !ok || sub.Type != configer.FieldTypeSection {
+			return errors.New("key `" + strings.Join(levels, ".") + "` conflicts with the value of `" + strings.Join(levels[:i+1], ".") + "`")
+		}
+		m = subMap
+	}
+	last := levels[len(levels)-1]
+	if old, ok := m[last]; ok && old.Type == configer.FieldTypeSection && f.Type != configer.FieldTypeSection {
+		return errors.New("key `" + strings.Join(levels, ".") + "` conflicts with a section")
+	} else if ok && old.Type != configer.FieldTypeSection && f.Type == configer.FieldTypeSection {
+		return errors.New("section `" + strings.Join(levels, ".") + "` conflicts with a value")
+	}
+	m[last] = f
+	return nil
+}
+
+// Leaves calls fn with the nested keys of every non-section field in key order
+func Leaves(configMap map[string]configer.Field, fn func(levels []string, f configer.Field)) {
+	leaves(configMap, nil, fn)
+}
+
+func leaves(configMap map[string]configer.Field, prefix []string, fn func(levels []string, f configer.Field)) {
+	keys := make([]string, 0, len(configMap))
+	for key := range configMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f := configMap[key]
+		levels := append(append([]string(nil), prefix...), key)
+		if subMap, ok := f.Value.(map[string]configer.Field); ok && f.Type == configer.FieldTypeSection {
+			leaves(subMap, levels, fn)
+			continue
+		}
+		fn(levels, f)
+	}
+}
+
+// Normalize converts the plain value into a canonical form for comparisons: maps become
+// `map[string]any`, slices `[]any`, integers int64 and floats float64
+func Normalize(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool, int64, float64:
+		return v
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = Normalize(value)
+		}
+		return m
+	case map[string]configer.Field:
+		return Normalize(ToMap(v))
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+	case reflect.Float32:
+		return rv.Float()
+	case reflect.Map:
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = Normalize(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = Normalize(rv.Index(i).Interface())
+		}
+		return s
+	}
+	return v
+}
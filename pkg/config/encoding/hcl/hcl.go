@@ -0,0 +1,627 @@
+package hcl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Decode decodes the given HCL2 bytes to the config map.
+//
+// Attributes become fields and blocks become sections nested by their labels
+// (`service "api" { ... }` becomes `service.api`), repeated blocks become slices of sections.
+// Only literal expressions are supported: strings, heredocs, numbers, booleans, null, tuples
+// and objects. Template interpolations such as `${var.name}` are kept as text.
+func Decode(b []byte) (map[string]configer.Field, error) {
+	p := &parser{src: []rune(string(b)), line: 1}
+	body, err := p.body(0)
+	if err != nil {
+		return nil, err
+	}
+	configMap := make(map[string]configer.Field, len(body))
+	for key, value := range body {
+		configMap[key] = configer.Atof(value)
+	}
+	return configMap, nil
+}
+
+// Encode encodes the given config map to HCL2 bytes. Sections become blocks and slices of
+// sections become repeated blocks, sections with keys which are not identifiers become objects.
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeBody(&buf, field.ToMap(m), 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type parser struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+// body parses attributes and blocks up to the end rune, 0 for the end of the input
+func (p *parser) body(end rune) (map[string]any, error) {
+	m := make(map[string]any)
+	attrs := make(map[string]bool)
+	for {
+		p.skip(true)
+		if p.pos >= len(p.src) {
+			if end != 0 {
+				return nil, p.errorf("unexpected end of input, `}` expected")
+			}
+			return m, nil
+		}
+		if p.src[p.pos] == end {
+			p.pos++
+			return m, nil
+		}
+		name := p.ident()
+		if name == "" {
+			return nil, p.errorf("attribute or block name expected")
+		}
+		p.skip(false)
+		if p.peek() == '=' {
+			p.pos++
+			value, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := m[name]; ok {
+				return nil, p.errorf("duplicate `" + name + "`")
+			}
+			attrs[name] = true
+			if value != nil {
+				m[name] = value
+			}
+		} else {
+			if attrs[name] {
+				return nil, p.errorf("block `" + name + "` conflicts with an attribute")
+			}
+			labels := []string{name}
+			for {
+				p.skip(false)
+				if p.peek() == '"' {
+					label, err := p.label()
+					if err != nil {
+						return nil, err
+					}
+					labels = append(labels, label)
+				} else if label := p.ident(); label != "" {
+					labels = append(labels, label)
+				} else {
+					break
+				}
+			}
+			if p.peek() != '{' {
+				return nil, p.errorf("`=` or `{` expected after `" + name + "`")
+			}
+			p.pos++
+			block, err := p.body('}')
+			if err != nil {
+				return nil, err
+			}
+			if err := addBlock(m, labels, block); err != nil {
+				return nil, p.errorf(err.Error())
+			}
+		}
+		p.skip(false)
+		if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != end {
+			return nil, p.errorf("newline expected after `" + name + "`")
+		}
+	}
+}
+
+// addBlock adds the block body at its labels, a repeated block turns the value into a slice
+func addBlock(m map[string]any, labels []string, block map[string]any) error {
+	for _, label := range labels[:len(labels)-1] {
+		v, ok := m[label]
+		if !ok {
+			sub := make(map[string]any)
+			m[label] = sub
+			m = sub
+			continue
+		}
+		sub, ok := v.(map[string]any)
+		if !ok {
+			return errors.New("block `" + strings.Join(labels, ".") + "` conflicts with `" + label + "`")
+		}
+		m = sub
+	}
+	last := labels[len(labels)-1]
+	switch v := m[last].(type) {
+	case nil:
+		m[last] = block
+	case map[string]any:
+		m[last] = []any{v, block}
+	case []any:
+		m[last] = append(v, block)
+	default:
+		return errors.New("block `" + strings.Join(labels, ".") + "` conflicts with an attribute")
+	}
+	return nil
+}
+
+func (p *parser) expr() (any, error) {
+	p.skip(false)
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expression expected")
+	}
+	switch r := p.src[p.pos]; {
+	case r == '"':
+		return p.template()
+	case r == '<' && p.at("<<"):
+		return p.heredoc()
+	case r == '[':
+		return p.tuple()
+	case r == '{':
+		return p.object()
+	case r == '-' || r >= '0' && r <= '9':
+		return p.number()
+	default:
+		name := p.ident()
+		switch name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		case "":
+			return nil, p.errorf("unexpected `" + string(r) + "`")
+		}
+		return nil, p.errorf("expression `" + name + "` not supported, only literal values are")
+	}
+}
+
+// template parses a quoted template, interpolations are kept as written
+func (p *parser) template() (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		p.pos++
+		switch {
+		case r == '"':
+			return sb.String(), nil
+		case r == '\n':
+			return "", p.errorf("unterminated string")
+		case r == '\\':
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteRune('\n')
+			case 'r':
+				sb.WriteRune('\r')
+			case 't':
+				sb.WriteRune('\t')
+			case '"', '\\':
+				sb.WriteRune(e)
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if p.pos+n > len(p.src) {
+					return "", p.errorf("malformed unicode escape")
+				}
+				code, err := strconv.ParseUint(string(p.src[p.pos:p.pos+n]), 16, 32)
+				if err != nil {
+					return "", p.errorf("malformed unicode escape")
+				}
+				sb.WriteRune(rune(code))
+				p.pos += n
+			default:
+				return "", p.errorf("invalid escape `\\" + string(e) + "`")
+			}
+		case (r == '$' || r == '%') && p.peek() == '{':
+			// interpolations and directives are kept as text
+			depth := 0
+			sb.WriteRune(r)
+			for p.pos < len(p.src) {
+				c := p.src[p.pos]
+				p.pos++
+				sb.WriteRune(c)
+				if c == '{' {
+					depth++
+				} else if c == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		case (r == '$' || r == '%') && p.at(string(r)+"{"):
+			// `$${` and `%%{` escape a literal `${` and `%{`
+			sb.WriteRune(r)
+			p.pos++
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// label parses a quoted block label
+func (p *parser) label() (string, error) {
+	return p.template()
+}
+
+// heredoc parses `<<EOF` and the indented `<<-EOF` form
+func (p *parser) heredoc() (string, error) {
+	p.pos += 2
+	indented := false
+	if p.peek() == '-' {
+		indented = true
+		p.pos++
+	}
+	marker := p.ident()
+	if marker == "" || p.peek() != '\n' {
+		return "", p.errorf("heredoc marker expected")
+	}
+	p.pos++
+	p.line++
+	var lines []string
+	for p.pos < len(p.src) {
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+			p.pos++
+		}
+		text := string(p.src[start:p.pos])
+		if strings.TrimSpace(text) == marker {
+			if indented {
+				lines = dedent(lines)
+			}
+			if len(lines) == 0 {
+				return "", nil
+			}
+			return strings.Join(lines, "\n") + "\n", nil
+		}
+		lines = append(lines, strings.TrimRight(text, "\r"))
+		if p.pos < len(p.src) {
+			p.pos++
+			p.line++
+		}
+	}
+	return "", p.errorf("unterminated heredoc `" + marker + "`")
+}
+
+// dedent removes the common leading whitespace of the lines
+func dedent(lines []string) []string {
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i, l := range lines {
+		if len(l) >= common && common > 0 {
+			lines[i] = l[common:]
+		}
+	}
+	return lines
+}
+
+func (p *parser) tuple() ([]any, error) {
+	p.pos++
+	values := []any{}
+	for {
+		p.skip(true)
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skip(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("`,` or `]` expected")
+		}
+	}
+}
+
+func (p *parser) object() (map[string]any, error) {
+	p.pos++
+	m := make(map[string]any)
+	for {
+		p.skip(true)
+		if p.peek() == '}' {
+			p.pos++
+			return m, nil
+		}
+		var key string
+		if p.peek() == '"' {
+			var err error
+			if key, err = p.template(); err != nil {
+				return nil, err
+			}
+		} else if key = p.ident(); key == "" {
+			return nil, p.errorf("object key expected")
+		}
+		p.skip(false)
+		if r := p.peek(); r != '=' && r != ':' {
+			return nil, p.errorf("`=` expected after object key `" + key + "`")
+		}
+		p.pos++
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+		p.skip(false)
+		switch p.peek() {
+		case ',', '\n':
+			p.pos++
+			if p.src[p.pos-1] == '\n' {
+				p.line++
+			}
+		case '}':
+		default:
+			return nil, p.errorf("`,` or `}` expected")
+		}
+	}
+}
+
+func (p *parser) number() (any, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	isFloat := false
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r >= '0' && r <= '9':
+		case r == '.' || r == 'e' || r == 'E':
+			isFloat = true
+		case (r == '+' || r == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E'):
+		default:
+			goto done
+		}
+		p.pos++
+	}
+done:
+	text := string(p.src[start:p.pos])
+	if !isFloat {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number `" + text + "`")
+	}
+	return f, nil
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if unicode.IsLetter(r) || r == '_' || p.pos > start && (unicode.IsDigit(r) || r == '-') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return string(p.src[start:p.pos])
+}
+
+// skip skips whitespace and comments, newlines only if asked
+func (p *parser) skip(newlines bool) {
+	for p.pos < len(p.src) {
+		switch r := p.src[p.pos]; {
+		case r == ' ' || r == '\t' || r == '\r':
+			p.pos++
+		case r == '\n' && newlines:
+			p.pos++
+			p.line++
+		case r == '#' || r == '/' && p.at("//"):
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case r == '/' && p.at("/*"):
+			p.pos += 2
+			for p.pos < len(p.src) && !p.at("*/") {
+				if p.src[p.pos] == '\n' {
+					p.line++
+				}
+				p.pos++
+			}
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) peek() rune {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) at(s string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:min(p.pos+len(s), len(p.src))]), s)
+}
+
+func (p *parser) errorf(msg string) error {
+	return errors.New("hcl: line " + strconv.Itoa(p.line) + ": " + msg)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// writeBody writes attributes first, then blocks
+func writeBody(buf *bytes.Buffer, m map[string]any, indent int) error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		if !isIdent(key) {
+			return errors.New("hcl: key `" + key + "` is not an identifier")
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pad := strings.Repeat("  ", indent)
+	start := buf.Len()
+	var blocks []string
+	for _, key := range keys {
+		if _, ok := blocksOf(m[key]); ok {
+			blocks = append(blocks, key)
+			continue
+		}
+		buf.WriteString(pad + key + " = " + expr(m[key], indent) + "\n")
+	}
+	for _, key := range blocks {
+		bodies, _ := blocksOf(m[key])
+		for _, body := range bodies {
+			if buf.Len() > start {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(pad + key + " {\n")
+			if err := writeBody(buf, body, indent+1); err != nil {
+				return err
+			}
+			buf.WriteString(pad + "}\n")
+		}
+	}
+	return nil
+}
+
+// blocksOf returns the block bodies of a section or a slice of sections whose keys are identifiers
+func blocksOf(v any) ([]map[string]any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		if identKeys(v) {
+			return []map[string]any{v}, true
+		}
+	case []any:
+		if len(v) == 0 {
+			return nil, false
+		}
+		bodies := make([]map[string]any, 0, len(v))
+		for _, e := range v {
+			body, ok := e.(map[string]any)
+			if !ok || !identKeys(body) {
+				return nil, false
+			}
+			bodies = append(bodies, body)
+		}
+		return bodies, true
+	}
+	return nil, false
+}
+
+func identKeys(m map[string]any) bool {
+	for key := range m {
+		if !isIdent(key) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdent(s string) bool {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || i > 0 && (unicode.IsDigit(r) || r == '-')) {
+			return false
+		}
+	}
+	return s != "" && s != "true" && s != "false" && s != "null"
+}
+
+// expr formats the value as a literal expression
+func expr(v any, indent int) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case time.Time:
+		return quote(v.Format(time.RFC3339Nano))
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pad := strings.Repeat("  ", indent+1)
+		var sb strings.Builder
+		sb.WriteString("{\n")
+		for _, key := range keys {
+			name := key
+			if !isIdent(key) {
+				name = quote(key)
+			}
+			sb.WriteString(pad + name + " = " + expr(v[key], indent+1) + "\n")
+		}
+		sb.WriteString(strings.Repeat("  ", indent) + "}")
+		return sb.String()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elems[i] = expr(rv.Index(i).Interface(), indent)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	if rv.Kind() == reflect.Map {
+		m := make(map[string]any, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[fmt.Sprint(key.Interface())] = rv.MapIndex(key).Interface()
+		}
+		return expr(m, indent)
+	}
+	return fmt.Sprint(v)
+}
+
+// formatFloat formats the float so it decodes to a float again, whole numbers keep a `.0`
+func formatFloat(f float64, bitSize int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".eEIN") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote quotes the string as a template, literal `${` and `%{` are escaped
+func quote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r", "\t", "\\t", "${", "$${", "%{", "%%{")
+	return "\"" + r.Replace(s) + "\""
+}
@@ -0,0 +1,100 @@
+package hcl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "attributes", src: "name = \"app\"\nport = 8080\nratio = 0.5\ndebug = true\nnothing = null\n", want: map[string]any{
+			"name": "app", "port": int64(8080), "ratio": 0.5, "debug": true,
+		}},
+		{name: "comments", src: "# hash\n// slashes\n/* block\ncomment */\na = 1 # trailing\n", want: map[string]any{"a": int64(1)}},
+		{name: "tuple and object", src: "tags = [\"a\", \"b\",]\nlimits = { cpu = 2, \"mem-gb\": 4 }\n", want: map[string]any{
+			"tags": []any{"a", "b"}, "limits": map[string]any{"cpu": int64(2), "mem-gb": int64(4)},
+		}},
+		{name: "block labels", src: "service \"api\" {\n  port = 80\n}\nservice web {\n  port = 81\n}\n", want: map[string]any{
+			"service": map[string]any{"api": map[string]any{"port": int64(80)}, "web": map[string]any{"port": int64(81)}},
+		}},
+		{name: "repeated blocks", src: "rule {\n  n = 1\n}\nrule {\n  n = 2\n}\nrule {\n  n = 3\n}\n", want: map[string]any{
+			"rule": []any{map[string]any{"n": int64(1)}, map[string]any{"n": int64(2)}, map[string]any{"n": int64(3)}},
+		}},
+		{name: "templates", src: `a = "${var.name}-%{if x}y%{endif}"` + "\n" + `b = "$${literal} é\t"` + "\n", want: map[string]any{
+			"a": "${var.name}-%{if x}y%{endif}", "b": "${literal} é\t",
+		}},
+		{name: "heredoc", src: "a = <<EOF\nline 1\n  line 2\nEOF\nb = <<-EOT\n    x\n      y\n    EOT\n", want: map[string]any{
+			"a": "line 1\n  line 2\n", "b": "x\n  y\n",
+		}},
+		{name: "numbers", src: "a = -3\nb = 1e3\nc = 2.5E-1\n", want: map[string]any{"a": int64(-3), "b": 1000.0, "c": 0.25}},
+		{name: "duplicate attribute", src: "a = 1\na = 2\n", wantErr: true},
+		{name: "block conflicts with attribute", src: "a = 1\na {\n}\n", wantErr: true},
+		{name: "expression", src: "a = var.name\n", wantErr: true},
+		{name: "unterminated block", src: "a {\n  b = 1\n", wantErr: true},
+		{name: "unterminated string", src: "a = \"x\n", wantErr: true},
+		{name: "unterminated heredoc", src: "a = <<EOF\nx\n", wantErr: true},
+		{name: "two attributes on a line", src: "a = 1 b = 2\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m := field.ToMap(got); !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	src := `name = "app ${x}"
+ratio = 2.0
+tags = ["a", "b"]
+labels = { "app.kubernetes.io/name" = "api" }
+
+server {
+  port = 8080
+  tls {
+    enabled = true
+  }
+}
+
+rule {
+  n = 1
+}
+rule {
+  n = 2
+}
+`
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode(Encode()) error = %v\n%s", err, b)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %#v, want %#v\n%s", field.ToMap(back), field.ToMap(m), b)
+	}
+	if _, err := Encode(map[string]configer.Field{"not an ident": configer.Atof(1)}); err == nil {
+		t.Error("Encode() of a key which is not an identifier succeeded")
+	}
+}
@@ -0,0 +1,304 @@
+package ini
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// line a line of an INI document, comment and blank lines have neither header nor key
+type line struct {
+	raw     string
+	header  bool
+	section []string
+	key     string
+	value   configer.Field
+}
+
+// levels returns the nested keys of the entry
+func (l line) levels() []string {
+	return append(append([]string(nil), l.section...), strings.Split(l.key, ".")...)
+}
+
+// Decode decodes the given INI bytes to the config map.
+//
+// `[section]` and `[section.sub]` headers open nested sections, entries are `key = value` or
+// `key: value` and `;` or `#` start comments. Quoted values are strings, unquoted values are
+// parsed into integers, floats and booleans. Dots in keys create nested sections as well.
+func Decode(b []byte) (map[string]configer.Field, error) {
+	lines, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	configMap := make(map[string]configer.Field)
+	for _, l := range lines {
+		switch {
+		case l.header:
+			if exists(configMap, l.section) {
+				continue
+			}
+			section := configer.Field{Type: configer.FieldTypeSection, Value: make(map[string]configer.Field)}
+			if err := field.SetLevels(configMap, l.section, section); err != nil {
+				return nil, errors.New("ini: " + err.Error())
+			}
+		case l.key != "":
+			if err := field.SetLevels(configMap, l.levels(), l.value); err != nil {
+				return nil, errors.New("ini: " + err.Error())
+			}
+		}
+	}
+	return configMap, nil
+}
+
+// Encode encodes the given config map to INI bytes, top level values come first and
+// every section with values gets a `[section.sub]` header
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeSection(&buf, m, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeSection(buf *bytes.Buffer, m map[string]configer.Field, section []string) error {
+	var subs []string
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		if key == "" || strings.ContainsAny(key, ".[]=:;#\n") {
+			return errors.New("ini: key `" + key + "` cannot be encoded")
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var entries []string
+	for _, key := range keys {
+		if m[key].Type == configer.FieldTypeSection {
+			subs = append(subs, key)
+			continue
+		}
+		entries = append(entries, key+" = "+format(m[key])+"\n")
+	}
+	if len(section) > 0 && (len(entries) > 0 || len(subs) == 0) {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("[" + strings.Join(section, ".") + "]\n")
+	}
+	for _, entry := range entries {
+		buf.WriteString(entry)
+	}
+	for _, key := range subs {
+		sub, _ := m[key].Value.(map[string]configer.Field)
+		if err := encodeSection(buf, sub, append(append([]string(nil), section...), key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodePreserving encodes the config map on top of the original document, comment lines and
+// unchanged entries are kept as written, removed keys are dropped, added keys are inserted at
+// the end of their section and new sections are appended
+func EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	lines, err := parse(original)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]configer.Field)
+	var order [][]string
+	field.Leaves(m, func(levels []string, f configer.Field) {
+		current[id(levels)] = f
+		order = append(order, levels)
+	})
+	// the last line of every section, new entries are inserted after it
+	ends := map[string]int{"": -1}
+	for i, l := range lines {
+		if l.header || l.key != "" {
+			ends[id(l.section)] = i
+		}
+	}
+	inserts := make(map[int][]string)
+	var newSections []string
+	added := make(map[string][]string)
+	for _, levels := range order {
+		full := id(levels)
+		if !containsEntry(lines, full) {
+			parent := id(levels[:len(levels)-1])
+			entry := levels[len(levels)-1] + " = " + format(current[full]) + "\n"
+			if end, ok := ends[parent]; ok {
+				inserts[end] = append(inserts[end], entry)
+				continue
+			}
+			if _, ok := added[parent]; !ok {
+				newSections = append(newSections, parent)
+			}
+			added[parent] = append(added[parent], entry)
+		}
+	}
+	var buf bytes.Buffer
+	for _, entry := range inserts[-1] {
+		buf.WriteString(entry)
+	}
+	for i, l := range lines {
+		if l.key == "" {
+			buf.WriteString(l.raw)
+		} else if f, ok := current[id(l.levels())]; ok {
+			if reflect.DeepEqual(f, l.value) {
+				buf.WriteString(l.raw)
+			} else {
+				buf.WriteString(l.key + " = " + format(f) + "\n")
+			}
+		}
+		if len(inserts[i]) > 0 && !strings.HasSuffix(l.raw, "\n") {
+			buf.WriteByte('\n')
+		}
+		for _, entry := range inserts[i] {
+			buf.WriteString(entry)
+		}
+	}
+	for _, section := range newSections {
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("[" + strings.ReplaceAll(section, "\x00", ".") + "]\n")
+		for _, entry := range added[section] {
+			buf.WriteString(entry)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func containsEntry(lines []line, full string) bool {
+	for _, l := range lines {
+		if l.key != "" && id(l.levels()) == full {
+			return true
+		}
+	}
+	return false
+}
+
+// id identifies nested keys in maps
+func id(levels []string) string {
+	return strings.Join(levels, "\x00")
+}
+
+func parse(b []byte) ([]line, error) {
+	var lines []line
+	var section []string
+	for i, raw := range strings.SplitAfter(string(b), "\n") {
+		if raw == "" {
+			continue
+		}
+		content := strings.TrimSpace(raw)
+		switch {
+		case content == "" || content[0] == ';' || content[0] == '#':
+			lines = append(lines, line{raw: raw, section: section})
+		case content[0] == '[':
+			end := strings.IndexByte(content, ']')
+			if end < 0 {
+				return nil, lineError(i, "unterminated section header")
+			}
+			if rest := strings.TrimSpace(content[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, lineError(i, "unexpected characters after section header")
+			}
+			section = nil
+			for _, level := range strings.Split(content[1:end], ".") {
+				section = append(section, strings.TrimSpace(level))
+			}
+			lines = append(lines, line{raw: raw, header: true, section: section})
+		default:
+			sep := strings.IndexAny(content, "=:")
+			if sep <= 0 {
+				return nil, lineError(i, "`key = value` expected")
+			}
+			value, err := parseValue(strings.TrimSpace(content[sep+1:]))
+			if err != nil {
+				return nil, lineError(i, err.Error())
+			}
+			key := strings.TrimSpace(content[:sep])
+			lines = append(lines, line{raw: raw, section: section, key: key, value: configer.Atof(value)})
+		}
+	}
+	return lines, nil
+}
+
+// parseValue parses a quoted string or an unquoted scalar with an optional inline comment
+func parseValue(s string) (any, error) {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		quote := s[0]
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] == quote:
+				if rest := strings.TrimSpace(s[i+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+					return nil, errors.New("unexpected characters after quoted value")
+				}
+				return sb.String(), nil
+			case s[i] == '\\' && quote == '"' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				case 'r':
+					sb.WriteByte('\r')
+				default:
+					sb.WriteByte(s[i])
+				}
+			default:
+				sb.WriteByte(s[i])
+			}
+		}
+		return nil, errors.New("unterminated quoted value")
+	}
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			s = strings.TrimSpace(s[:i])
+			break
+		}
+	}
+	return field.ParseScalar(s), nil
+}
+
+// format formats the value, strings which would not decode to themselves are quoted
+func format(f configer.Field) string {
+	s, ok := f.Value.(string)
+	if !ok {
+		return fmt.Sprint(field.Ftoa(f))
+	}
+	if s != "" && s == strings.TrimSpace(s) && !strings.ContainsAny(s, "\"';#\n\r\t") {
+		if _, typed := field.ParseScalar(s).(string); typed {
+			return s
+		}
+	}
+	return strconv.Quote(s)
+}
+
+func exists(configMap map[string]configer.Field, levels []string) bool {
+	m := configMap
+	for _, level := range levels {
+		f, ok := m[level]
+		if !ok {
+			return false
+		}
+		if m, ok = f.Value.(map[string]configer.Field); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func lineError(i int, msg string) error {
+	return errors.New("ini: line " + strconv.Itoa(i+1) + ": " + msg)
+}
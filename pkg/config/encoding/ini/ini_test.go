@@ -0,0 +1,101 @@
+package ini
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "top level", src: "name = app\nport: 8080\n", want: map[string]any{"name": "app", "port": int64(8080)}},
+		{name: "sections", src: "[db]\nhost = localhost\n[db.replica]\nhost = replica\n", want: map[string]any{
+			"db": map[string]any{"host": "localhost", "replica": map[string]any{"host": "replica"}},
+		}},
+		{name: "empty section", src: "[empty]\n", want: map[string]any{"empty": map[string]any{}}},
+		{name: "comments", src: "; comment\n# comment\n[s] ; header comment\na = 1 ; inline\nb = x#y\n", want: map[string]any{
+			"s": map[string]any{"a": int64(1), "b": "x#y"},
+		}},
+		{name: "quoted", src: "a = \"8080\"\nb = 'x ; y'\nc = \"a\\tb\"\n", want: map[string]any{
+			"a": "8080", "b": "x ; y", "c": "a\tb",
+		}},
+		{name: "dotted keys", src: "[s]\na.b = true\n", want: map[string]any{
+			"s": map[string]any{"a": map[string]any{"b": true}},
+		}},
+		{name: "unterminated header", src: "[s\n", wantErr: true},
+		{name: "missing separator", src: "key\n", wantErr: true},
+		{name: "unterminated quote", src: "a = \"x\n", wantErr: true},
+		{name: "value and section", src: "a = 1\n[a]\nb = 2\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m := field.ToMap(got); !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	src := "name = app\n[db]\nhost = localhost\nport = \"5432\"\n[db.replica]\nhost = \"a ; b\"\n"
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %#v, want %#v\n%s", field.ToMap(back), field.ToMap(m), b)
+	}
+	if _, err := Encode(map[string]configer.Field{"a.b": configer.Atof(1)}); err == nil {
+		t.Error("Encode() of a dotted key succeeded")
+	}
+}
+
+func TestEncodePreserving(t *testing.T) {
+	src := "; app\nname = app\n\n[db]\n# host\nhost = localhost\nold = 1\n"
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unchanged, err := EncodePreserving([]byte(src), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(unchanged) != src {
+		t.Errorf("EncodePreserving() of the unchanged map =\n%s\nwant the original document", unchanged)
+	}
+	db := m["db"].Value.(map[string]configer.Field)
+	db["host"] = configer.Atof("db")
+	db["port"] = configer.Atof(int64(5432))
+	delete(db, "old")
+	m["cache"] = configer.Field{Type: configer.FieldTypeSection, Value: map[string]configer.Field{"ttl": configer.Atof(int64(60))}}
+	got, err := EncodePreserving([]byte(src), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "; app\nname = app\n\n[db]\n# host\nhost = db\nport = 5432\n\n[cache]\nttl = 60\n"
+	if string(got) != want {
+		t.Errorf("EncodePreserving() =\n%s\nwant\n%s", got, want)
+	}
+}
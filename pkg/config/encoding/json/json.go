@@ -0,0 +1,59 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Decode decodes the given JSON object bytes to the config map, integral numbers become int64
+func Decode(b []byte) (map[string]configer.Field, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	decodeMap, ok := numbers(doc).(map[string]any)
+	if !ok {
+		return nil, errors.New("json: document is not an object")
+	}
+	configMap := make(map[string]configer.Field, len(decodeMap))
+	for k, v := range decodeMap {
+		configMap[k] = configer.Atof(v)
+	}
+	return configMap, nil
+}
+
+// Encode encodes the given config map to indented JSON object bytes
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	b, err := json.MarshalIndent(field.ToMap(m), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// numbers converts json.Number values to int64 or float64
+func numbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			v[key] = numbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = numbers(value)
+		}
+	}
+	return v
+}
@@ -0,0 +1,34 @@
+package encoding
+
+type registerOptions struct {
+	aliases  []string
+	priority int
+}
+
+// RegisterOption option interface for codec registration
+type RegisterOption interface {
+	apply(opts *registerOptions)
+}
+
+// WithAliases with aliases option, the codec is looked up by the aliases as by its name
+func WithAliases(aliases ...string) RegisterOption {
+	return aliasesOption(aliases)
+}
+
+// WithPriority with priority option, the codec with the highest priority wins
+// the extensions and MIME types claimed by several codecs, 0 by default
+func WithPriority(priority int) RegisterOption {
+	return priorityOption(priority)
+}
+
+type aliasesOption []string
+
+func (o aliasesOption) apply(opts *registerOptions) {
+	opts.aliases = append(opts.aliases, o...)
+}
+
+type priorityOption int
+
+func (o priorityOption) apply(opts *registerOptions) {
+	opts.priority = int(o)
+}
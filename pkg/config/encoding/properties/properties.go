@@ -0,0 +1,274 @@
+package properties
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// ValueKey the key under which a key that is also the prefix of other keys keeps its own value,
+// e.g. `log4j.appender.stdout` beside `log4j.appender.stdout.layout`
+const ValueKey = "#value"
+
+// line a logical line of a properties document, a comment or blank line has no key
+type line struct {
+	raw   string
+	key   string
+	value configer.Field
+}
+
+// Decode decodes the given Java properties bytes to the config map.
+//
+// `#` and `!` start comment lines, a trailing `\` continues the line, keys are separated from
+// values by `=`, `:` or whitespace and `\uXXXX` escapes are supported. Dots in keys create
+// nested sections and values are parsed into integers, floats and booleans. A key which is
+// also the prefix of other keys becomes a section keeping its own value under ValueKey.
+func Decode(b []byte) (map[string]configer.Field, error) {
+	lines, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make(map[string]bool)
+	for _, l := range lines {
+		for i := strings.IndexByte(l.key, '.'); i >= 0; i = next(l.key, i) {
+			prefixes[l.key[:i]] = true
+		}
+	}
+	configMap := make(map[string]configer.Field)
+	for _, l := range lines {
+		if l.key == "" {
+			continue
+		}
+		levels := strings.Split(l.key, ".")
+		if prefixes[l.key] {
+			levels = append(levels, ValueKey)
+		}
+		if err := field.SetLevels(configMap, levels, l.value); err != nil {
+			return nil, errors.New("properties: " + err.Error())
+		}
+	}
+	return configMap, nil
+}
+
+// next returns the index of the dot after i in the key, -1 if there is none
+func next(key string, i int) int {
+	if j := strings.IndexByte(key[i+1:], '.'); j >= 0 {
+		return i + 1 + j
+	}
+	return -1
+}
+
+// Encode encodes the given config map to Java properties bytes, nested keys are joined with `.`
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	var buf bytes.Buffer
+	field.Leaves(m, func(levels []string, f configer.Field) {
+		buf.WriteString(format(join(levels), f))
+	})
+	return buf.Bytes(), nil
+}
+
+// join joins the nested keys, a trailing ValueKey is the value of its section
+func join(levels []string) string {
+	if len(levels) > 1 && levels[len(levels)-1] == ValueKey {
+		levels = levels[:len(levels)-1]
+	}
+	return strings.Join(levels, ".")
+}
+
+// EncodePreserving encodes the config map on top of the original document, comment lines and
+// unchanged entries are kept as written, removed keys are dropped and added keys are appended
+func EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	lines, err := parse(original)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]configer.Field)
+	var order []string
+	field.Leaves(m, func(levels []string, f configer.Field) {
+		key := join(levels)
+		current[key] = f
+		order = append(order, key)
+	})
+	var buf bytes.Buffer
+	for _, l := range lines {
+		if l.key == "" {
+			buf.WriteString(l.raw)
+			continue
+		}
+		f, ok := current[l.key]
+		if !ok {
+			continue
+		}
+		delete(current, l.key)
+		if reflect.DeepEqual(f, l.value) {
+			buf.WriteString(l.raw)
+		} else {
+			buf.WriteString(format(l.key, f))
+		}
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	for _, key := range order {
+		if f, ok := current[key]; ok {
+			buf.WriteString(format(key, f))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// parse splits the document into logical lines, the raw text keeps continuation lines
+func parse(b []byte) ([]line, error) {
+	var lines []line
+	physical := strings.SplitAfter(string(b), "\n")
+	for i := 0; i < len(physical); i++ {
+		if physical[i] == "" {
+			continue
+		}
+		raw := physical[i]
+		trimmed := strings.TrimLeft(raw, " \t\f")
+		if content := strings.TrimRight(trimmed, "\r\n"); content == "" || content[0] == '#' || content[0] == '!' {
+			lines = append(lines, line{raw: raw})
+			continue
+		}
+		logical := strings.TrimRight(trimmed, "\r\n")
+		for continues(logical) && i+1 < len(physical) {
+			i++
+			raw += physical[i]
+			logical = logical[:len(logical)-1] + strings.TrimLeft(strings.TrimRight(physical[i], "\r\n"), " \t\f")
+		}
+		if continues(logical) {
+			logical = logical[:len(logical)-1]
+		}
+		key, value, err := split(logical)
+		if err != nil {
+			return nil, errors.New("properties: line " + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+		lines = append(lines, line{raw: raw, key: key, value: configer.Atof(field.ParseScalar(value))})
+	}
+	return lines, nil
+}
+
+// continues reports whether the line ends with an odd number of backslashes
+func continues(s string) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// split splits the logical line into its unescaped key and value
+func split(s string) (string, string, error) {
+	end := 0
+	for end < len(s) {
+		c := s[end]
+		if c == '\\' {
+			end += 2
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+		end++
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	rest := strings.TrimLeft(s[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	key, err := unescape(s[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescape(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", errors.New("malformed \\uxxxx escape")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", errors.New("malformed \\uxxxx escape")
+			}
+			sb.WriteRune(rune(r))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+// format formats the entry as a properties line
+func format(key string, f configer.Field) string {
+	value := fmt.Sprint(field.Ftoa(f))
+	if s, ok := f.Value.(string); ok {
+		value = s
+	}
+	return escape(key, true) + "=" + escape(value, false) + "\n"
+}
+
+func escape(s string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString("\\\\")
+		case r == '\n':
+			sb.WriteString("\\n")
+		case r == '\r':
+			sb.WriteString("\\r")
+		case r == '\t':
+			sb.WriteString("\\t")
+		case r == '\f':
+			sb.WriteString("\\f")
+		case r == ' ' && (isKey || i == 0):
+			sb.WriteString("\\ ")
+		case (r == '=' || r == ':') && isKey:
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case (r == '#' || r == '!') && i == 0:
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 0x20:
+			sb.WriteString(fmt.Sprintf("\\u%04x", r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package properties
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "separators", src: "a=1\nb: two\nc three\nd\n", want: map[string]any{
+			"a": int64(1), "b": "two", "c": "three", "d": "",
+		}},
+		{name: "comments", src: "# comment\n! other\n\nname=app\n", want: map[string]any{"name": "app"}},
+		{name: "continuation", src: "list=a,\\\n    b,\\\n    c\n", want: map[string]any{"list": "a,b,c"}},
+		{name: "escaped backslash is no continuation", src: "path=c:\\\\\nnext=1\n", want: map[string]any{
+			"path": "c:\\", "next": int64(1),
+		}},
+		{name: "escapes", src: "key\\ with\\=sep=caf\\u00e9\\t\n", want: map[string]any{"key with=sep": "café\t"}},
+		{name: "sections", src: "db.host=localhost\ndb.port=5432\n", want: map[string]any{
+			"db": map[string]any{"host": "localhost", "port": int64(5432)},
+		}},
+		{name: "value and prefix", src: "a.b=1\na=0\na.b.c=2\n", want: map[string]any{
+			"a": map[string]any{ValueKey: int64(0), "b": map[string]any{ValueKey: int64(1), "c": int64(2)}},
+		}},
+		{name: "malformed unicode escape", src: "a=\\u12\n", wantErr: true},
+		{name: "empty level", src: "a..b=1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m := field.ToMap(got); !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestLog4j(t *testing.T) {
+	src, err := os.ReadFile("testdata/log4j.properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Decode(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appender := field.ToMap(m)["log4j"].(map[string]any)["appender"].(map[string]any)["stdout"].(map[string]any)
+	if appender[ValueKey] != "org.apache.log4j.ConsoleAppender" || appender["Target"] != "System.out" {
+		t.Errorf("log4j.appender.stdout = %v, want the appender and its options", appender)
+	}
+	layout := appender["layout"].(map[string]any)
+	if layout["ConversionPattern"] != "%d{yyyy-MM-dd HH:mm:ss} %-5p %c{1}:%L - %m%n" {
+		t.Errorf("ConversionPattern = %q", layout["ConversionPattern"])
+	}
+
+	encoded, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), "log4j.appender.stdout=org.apache.log4j.ConsoleAppender\n") {
+		t.Errorf("Encode() lost the value of a prefix key:\n%s", encoded)
+	}
+	back, err := Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %v, want %v", field.ToMap(back), field.ToMap(m))
+	}
+
+	preserved, err := EncodePreserving(src, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(preserved) != string(src) {
+		t.Errorf("EncodePreserving() of the unchanged map =\n%s\nwant the original document", preserved)
+	}
+}
+
+func TestEncodePreserving(t *testing.T) {
+	src := "# database\ndb.host=localhost\n! port\ndb.port = 5432\nold=1\n"
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := m["db"].Value.(map[string]configer.Field)
+	db["port"] = configer.Atof(int64(6543))
+	delete(m, "old")
+	m["name"] = configer.Atof("my app")
+	got, err := EncodePreserving([]byte(src), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# database\ndb.host=localhost\n! port\ndb.port=6543\nname=my app\n"
+	if string(got) != want {
+		t.Errorf("EncodePreserving() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEncodeEscapes(t *testing.T) {
+	m := map[string]configer.Field{
+		"key with=sep": configer.Atof(" leading\nnewline"),
+		"#hash":        configer.Atof("!bang"),
+	}
+	b, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %#v, want %#v\n%s", field.ToMap(back), field.ToMap(m), b)
+	}
+}
@@ -0,0 +1,262 @@
+package encoding
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Registry codec registry looking up codecs by name, alias, file extension or MIME type,
+// it is safe for concurrent use
+type Registry struct {
+	mu sync.RWMutex
+	// codecs the registrations by name
+	codecs map[string]*registration
+	// aliases the names of aliases
+	aliases map[string]string
+	// seq the registration sequence, later registrations win ties
+	seq int
+}
+
+// registration a registered codec
+type registration struct {
+	name      string
+	codec     configer.Codec
+	exts      []string
+	mimeTypes []string
+	priority  int
+	seq       int
+}
+
+// DefaultRegistry the registry of the built-in codecs, used by the package functions
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry new empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		codecs:  make(map[string]*registration),
+		aliases: make(map[string]string),
+	}
+}
+
+// Register registers the codec under the name for the file extensions and MIME types, a codec
+// registered again under the same name replaces the previous one. When several codecs claim
+// an extension or MIME type the one with the highest priority wins, then the latest registered.
+func (r *Registry) Register(name string, codec configer.Codec, exts []string, mimeTypes []string, opts ...RegisterOption) error {
+	name = strings.ToLower(name)
+	if name == "" {
+		return errors.New("codec name is empty")
+	}
+	if codec == nil {
+		return errors.New("codec `" + name + "` is nil")
+	}
+	options := &registerOptions{}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	reg := &registration{
+		name:     name,
+		codec:    codec,
+		priority: options.priority,
+	}
+	for _, ext := range exts {
+		reg.exts = append(reg.exts, normalizeExt(ext))
+	}
+	for _, mimeType := range mimeTypes {
+		reg.mimeTypes = append(reg.mimeTypes, normalizeMediaType(mimeType))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if target, ok := r.aliases[name]; ok {
+		return errors.New("codec name `" + name + "` is an alias of `" + target + "`")
+	}
+	for _, alias := range options.aliases {
+		alias = strings.ToLower(alias)
+		if _, ok := r.codecs[alias]; ok {
+			return errors.New("codec alias `" + alias + "` is a codec name")
+		}
+	}
+	r.seq++
+	reg.seq = r.seq
+	r.codecs[name] = reg
+	for _, alias := range options.aliases {
+		r.aliases[strings.ToLower(alias)] = name
+	}
+	return nil
+}
+
+// Alias registers the alias for the codec name
+func (r *Registry) Alias(alias string, name string) error {
+	alias, name = strings.ToLower(alias), strings.ToLower(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codecs[alias]; ok {
+		return errors.New("codec alias `" + alias + "` is a codec name")
+	}
+	if target, ok := r.aliases[name]; ok {
+		name = target
+	}
+	r.aliases[alias] = name
+	return nil
+}
+
+// replace replaces the codec of the registration, registering it for the extension of the name if missing
+func (r *Registry) replace(name string, codec configer.Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name = r.resolve(strings.ToLower(name))
+	r.seq++
+	if reg, ok := r.codecs[name]; ok {
+		replaced := *reg
+		replaced.codec, replaced.seq = codec, r.seq
+		r.codecs[name] = &replaced
+		return
+	}
+	r.codecs[name] = &registration{name: name, codec: codec, exts: []string{name}, seq: r.seq}
+}
+
+// Unregister removes the codec registered under the name and its aliases
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name = r.resolve(strings.ToLower(name))
+	delete(r.codecs, name)
+	for alias, target := range r.aliases {
+		if target == name {
+			delete(r.aliases, alias)
+		}
+	}
+}
+
+// Lookup returns the codec registered under the name or alias
+func (r *Registry) Lookup(name string) (configer.Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.codecs[r.resolve(strings.ToLower(name))]
+	if !ok {
+		return nil, false
+	}
+	return reg.codec, true
+}
+
+// Name returns the codec name of the name or alias
+func (r *Registry) Name(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name = r.resolve(strings.ToLower(name))
+	_, ok := r.codecs[name]
+	return name, ok
+}
+
+// ByExtension returns the name and the codec for the file extension, with or without the leading dot
+func (r *Registry) ByExtension(ext string) (string, configer.Codec, bool) {
+	ext = normalizeExt(ext)
+	if ext == "" {
+		return "", nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.best(func(reg *registration) bool {
+		return contains(reg.exts, ext)
+	})
+}
+
+// ByMIME returns the name and the codec for the Content-Type, structured syntax suffixes
+// such as `+json` fall back to the codec named by the suffix
+func (r *Registry) ByMIME(contentType string) (string, configer.Codec, bool) {
+	mediaType := normalizeMediaType(contentType)
+	if mediaType == "" {
+		return "", nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, codec, ok := r.best(func(reg *registration) bool {
+		return contains(reg.mimeTypes, mediaType)
+	}); ok {
+		return name, codec, true
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		if reg, ok := r.codecs[r.resolve(mediaType[i+1:])]; ok {
+			return reg.name, reg.codec, true
+		}
+	}
+	return "", nil, false
+}
+
+// Names returns the sorted codec names
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.codecs))
+	for name := range r.codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// codecsByName returns the codecs by name and alias
+func (r *Registry) codecsByName() map[string]configer.Codec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codecs := make(map[string]configer.Codec, len(r.codecs)+len(r.aliases))
+	for name, reg := range r.codecs {
+		codecs[name] = reg.codec
+	}
+	for alias, name := range r.aliases {
+		if reg, ok := r.codecs[name]; ok {
+			codecs[alias] = reg.codec
+		}
+	}
+	return codecs
+}
+
+// resolve returns the codec name of the alias, the lock must be held
+func (r *Registry) resolve(name string) string {
+	if target, ok := r.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// best returns the matching registration with the highest priority, the lock must be held
+func (r *Registry) best(match func(reg *registration) bool) (string, configer.Codec, bool) {
+	var found *registration
+	for _, reg := range r.codecs {
+		if !match(reg) {
+			continue
+		}
+		if found == nil || reg.priority > found.priority || reg.priority == found.priority && reg.seq > found.seq {
+			found = reg
+		}
+	}
+	if found == nil {
+		return "", nil, false
+	}
+	return found.name, found.codec, true
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+func normalizeMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package encoding
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// namedCodec a codec told apart by its name
+type namedCodec struct {
+	name string
+}
+
+func (c *namedCodec) Decode(b []byte) (map[string]configer.Field, error) {
+	return map[string]configer.Field{}, nil
+}
+
+func (c *namedCodec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return []byte(c.name), nil
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	first, second, third := &namedCodec{"first"}, &namedCodec{"second"}, &namedCodec{"third"}
+	if err := r.Register("First", first, []string{".cfg", "conf"}, []string{"application/x-first"}, WithAliases("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("second", second, []string{"cfg"}, []string{"application/x-first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("third", third, []string{"conf"}, nil, WithPriority(-1)); err != nil {
+		t.Fatal(err)
+	}
+	lookups := []struct {
+		name   string
+		lookup func() (string, configer.Codec, bool)
+		want   configer.Codec
+	}{
+		{name: "name", lookup: func() (string, configer.Codec, bool) { c, ok := r.Lookup("FIRST"); return "", c, ok }, want: first},
+		{name: "alias", lookup: func() (string, configer.Codec, bool) { c, ok := r.Lookup("one"); return "", c, ok }, want: first},
+		{name: "latest wins extension", lookup: func() (string, configer.Codec, bool) { return r.ByExtension(".CFG") }, want: second},
+		{name: "priority wins extension", lookup: func() (string, configer.Codec, bool) { return r.ByExtension("conf") }, want: first},
+		{name: "latest wins MIME", lookup: func() (string, configer.Codec, bool) {
+			return r.ByMIME("application/x-first; charset=utf-8")
+		}, want: second},
+		{name: "MIME suffix", lookup: func() (string, configer.Codec, bool) { return r.ByMIME("application/vnd.x+third") }, want: third},
+		{name: "unknown extension", lookup: func() (string, configer.Codec, bool) { return r.ByExtension("ini") }},
+		{name: "empty extension", lookup: func() (string, configer.Codec, bool) { return r.ByExtension("") }},
+		{name: "invalid MIME", lookup: func() (string, configer.Codec, bool) { return r.ByMIME(";") }},
+	}
+	for _, tt := range lookups {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, ok := tt.lookup()
+			if ok != (tt.want != nil) || ok && got != tt.want {
+				t.Errorf("lookup = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+	if name, ok := r.Name("ONE"); !ok || name != "first" {
+		t.Errorf("Name(ONE) = %q, %v, want first", name, ok)
+	}
+
+	errs := []struct {
+		name  string
+		codec configer.Codec
+		opts  []RegisterOption
+	}{
+		{name: "", codec: first},
+		{name: "nil", codec: nil},
+		{name: "one", codec: first},
+		{name: "fourth", codec: first, opts: []RegisterOption{WithAliases("second")}},
+	}
+	for _, tt := range errs {
+		if err := r.Register(tt.name, tt.codec, nil, nil, tt.opts...); err == nil {
+			t.Errorf("Register(%q) succeeded, want an error", tt.name)
+		}
+	}
+	if err := r.Alias("second", "first"); err == nil {
+		t.Error("Alias() of a codec name succeeded")
+	}
+	if err := r.Alias("uno", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := r.Lookup("uno"); !ok || c != first {
+		t.Errorf("Lookup(uno) = %v, %v, want the codec of the alias target", c, ok)
+	}
+
+	replaced := &namedCodec{"replaced"}
+	r.replace("one", replaced)
+	if _, c, ok := r.ByExtension("conf"); !ok || c != replaced {
+		t.Errorf("ByExtension(conf) after replace = %v, want the replaced codec keeping the extensions", c)
+	}
+	r.replace("fresh", replaced)
+	if name, c, ok := r.ByExtension("fresh"); !ok || name != "fresh" || c != replaced {
+		t.Errorf("ByExtension(fresh) = %q, %v, want the codec registered for the extension of its name", name, c)
+	}
+	r.Unregister("uno")
+	if _, ok := r.Lookup("one"); ok {
+		t.Error("alias still resolves after Unregister")
+	}
+	if names := r.Names(); len(names) != 3 || names[0] != "fresh" || names[1] != "second" || names[2] != "third" {
+		t.Errorf("Names() = %v, want [fresh second third]", names)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := "codec" + strconv.Itoa(i)
+			for j := 0; j < 100; j++ {
+				_ = r.Register(name, &namedCodec{name}, []string{"cfg"}, []string{"text/x-cfg"})
+				r.ByExtension("cfg")
+				r.ByMIME("text/x-cfg")
+				r.Lookup(name)
+				r.Names()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(r.Names()) != 8 {
+		t.Errorf("Names() = %v, want 8 codecs", r.Names())
+	}
+}
+
+func TestBuiltinCodecs(t *testing.T) {
+	for _, ext := range []string{"toml", "yml", "yaml", "env", "properties", "ini", "hcl", "xml", "json"} {
+		if _, _, ok := ByExtension(ext); !ok {
+			t.Errorf("ByExtension(%q) not registered", ext)
+		}
+	}
+	for _, name := range []string{"toml", "yml", "yaml", "env", "dotenv", "json"} {
+		codec, ok := Lookup(name)
+		if !ok || !IsSupport(name) || GetSupport(name) != codec {
+			t.Errorf("Lookup(%q) not registered", name)
+		}
+		if SupportSet[EncType(name)] != codec {
+			t.Errorf("SupportSet[%q] = %v, want the registered codec", name, SupportSet[EncType(name)])
+		}
+	}
+}
@@ -1,38 +1,114 @@
 package encoding
 
-import "github.com/jacksonCLyu/ridi-faces/pkg/configer"
+import (
+	"errors"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/xml"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/yml"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
 
 type EncType string
 
 const (
 	Toml EncType = "toml"
 	Yml  EncType = "yml"
+	Env  EncType = "env"
+
+	Properties EncType = "properties"
+	Ini        EncType = "ini"
+	Hcl        EncType = "hcl"
+	Xml        EncType = "xml"
+	Json       EncType = "json"
 )
 
 func (e EncType) String() string {
 	return string(e)
 }
 
+// PreservingEncoder is implemented by codecs which can encode the config map on top of
+// the original document, keeping its comments and layout
+type PreservingEncoder interface {
+	EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error)
+}
+
+// Profiler is implemented by codecs of formats with profile documents, such as multi-document YAML
+type Profiler interface {
+	// ForProfile returns a codec of the same format which selects the documents of the profile
+	ForProfile(profile string) configer.Codec
+}
+
+var errNotDetected = errors.New("config format not detected")
+
+// SupportSet the built-in codecs by name and alias, a snapshot of the default registry taken
+// at package init. It is neither updated by registrations nor read by this package.
+//
+// Deprecated: use Lookup, ByExtension or ByMIME, and Register or AddSupport to add codecs.
 var SupportSet = make(map[EncType]configer.Codec)
 
-// Init support set
-func Init() {
-	SupportSet[Toml] = &tomlCodec{}
-	SupportSet[Yml] = &ymlCodec{}
+// register the built-in codecs
+func init() {
+	mustRegister(Toml.String(), DefaultCodec, []string{"toml"},
+		[]string{"application/toml", "application/x-toml", "text/x-toml"})
+	mustRegister(Yml.String(), yml.NewCodec(), []string{"yml", "yaml"},
+		[]string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}, WithAliases("yaml"))
+	mustRegister(Env.String(), &envCodec{}, []string{"env"}, nil, WithAliases("dotenv"))
+	mustRegister(Properties.String(), &propertiesCodec{}, []string{"properties"},
+		[]string{"text/x-java-properties"})
+	mustRegister(Ini.String(), &iniCodec{}, []string{"ini"}, nil)
+	mustRegister(Hcl.String(), &hclCodec{}, []string{"hcl"}, nil)
+	mustRegister(Xml.String(), xml.NewCodec(), []string{"xml"}, []string{"application/xml", "text/xml"})
+	mustRegister(Json.String(), &jsonCodec{}, []string{"json"}, []string{"application/json", "text/json"})
+	for name, codec := range DefaultRegistry.codecsByName() {
+		SupportSet[EncType(name)] = codec
+	}
+}
+
+func mustRegister(name string, codec configer.Codec, exts []string, mimeTypes []string, opts ...RegisterOption) {
+	if err := DefaultRegistry.Register(name, codec, exts, mimeTypes, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Init init support set
+//
+// Deprecated: the built-in codecs are registered at package init, Init does nothing.
+func Init() {}
+
+// Register registers the codec in the default registry, see Registry.Register
+func Register(name string, codec configer.Codec, exts []string, mimeTypes []string, opts ...RegisterOption) error {
+	return DefaultRegistry.Register(name, codec, exts, mimeTypes, opts...)
+}
+
+// Lookup returns the codec of the name or alias from the default registry
+func Lookup(name string) (configer.Codec, bool) {
+	return DefaultRegistry.Lookup(name)
+}
+
+// ByExtension returns the name and the codec for the file extension from the default registry
+func ByExtension(ext string) (string, configer.Codec, bool) {
+	return DefaultRegistry.ByExtension(ext)
+}
+
+// ByMIME returns the name and the codec for the Content-Type from the default registry
+func ByMIME(contentType string) (string, configer.Codec, bool) {
+	return DefaultRegistry.ByMIME(contentType)
 }
 
 // IsSupport check if support
 func IsSupport(t string) bool {
-	_, ok := SupportSet[EncType(t)]
+	_, ok := DefaultRegistry.Lookup(t)
 	return ok
 }
 
 // GetSupport get support codec
 func GetSupport(t string) configer.Codec {
-	return SupportSet[EncType(t)]
+	codec, _ := DefaultRegistry.Lookup(t)
+	return codec
 }
 
-// AddSupport add support
+// AddSupport add support, the codec replaces the codec registered under the name
+// or is registered for the extension of the same name
 func AddSupport(t string, c configer.Codec) {
-	SupportSet[EncType(t)] = c
+	DefaultRegistry.replace(t, c)
 }
@@ -0,0 +1,663 @@
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+	toml2 "github.com/pelletier/go-toml/v2"
+)
+
+// EncodePreserving encodes the config map on top of the original document. Comments, key order
+// and formatting are kept, only changed values are rewritten, removed keys are dropped and added
+// keys are inserted at the end of their table. The result falls back to Encode if it would not
+// decode to the config map.
+func EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	current := field.Normalize(m).(map[string]any)
+	out, err := patch(string(original), current)
+	if err == nil {
+		if decoded, derr := Decode([]byte(out)); derr == nil && reflect.DeepEqual(field.Normalize(decoded), current) {
+			return []byte(out), nil
+		}
+	}
+	return Encode(m)
+}
+
+const (
+	stmtTrivia = iota
+	stmtTable
+	stmtArrayTable
+	stmtKeyValue
+)
+
+// stmt a statement of the document, a key-value may span lines
+type stmt struct {
+	kind  int
+	start int
+	end   int
+	// path the resolved path of tables and key-values, array table elements are indexes
+	path []any
+	// valueStart and valueEnd the offsets of the value of a key-value
+	valueStart int
+	valueEnd   int
+}
+
+func patch(src string, current map[string]any) (string, error) {
+	decoded := make(map[string]any)
+	if err := toml2.Unmarshal([]byte(src), &decoded); err != nil {
+		return "", err
+	}
+	originalMap := field.Normalize(decoded).(map[string]any)
+	stmts, err := scan(src)
+	if err != nil {
+		return "", err
+	}
+	// scopes maps tables and array table elements to the statement new keys are inserted after
+	scopes := map[string]int{"": -1}
+	// tables holds table headers, values holds key-values which cover their whole value
+	tables := make(map[string]bool)
+	values := make(map[string]bool)
+	arrayTables := make(map[string]int)
+	scope := ""
+	for i, s := range stmts {
+		switch s.kind {
+		case stmtTable, stmtArrayTable:
+			scope = pathID(s.path)
+			scopes[scope] = i
+			tables[scope] = true
+			if s.kind == stmtArrayTable {
+				arrayTables[pathID(s.path[:len(s.path)-1])] = s.path[len(s.path)-1].(int) + 1
+			}
+		case stmtKeyValue:
+			scopes[scope] = i
+			values[pathID(s.path)] = true
+		}
+	}
+	inserts := make(map[int][]string)
+	var appended []string
+	// new top level tables are appended with a header
+	newTables := make(map[string][]string)
+	var newTableOrder []string
+	var walk func(path []any, v any) error
+	walk = func(path []any, v any) error {
+		id := pathID(path)
+		if values[id] {
+			return nil
+		}
+		if n, ok := arrayTables[id]; ok {
+			elems, ok := v.([]any)
+			if !ok {
+				return errors.New("array of tables replaced")
+			}
+			for i, elem := range elems {
+				elemPath := append(append([]any(nil), path...), i)
+				if i >= n {
+					block, err := toml2.Marshal(nest(path, []any{elem}))
+					if err != nil {
+						return err
+					}
+					appended = append(appended, string(block))
+					continue
+				}
+				if err := walk(elemPath, elem); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if sub, ok := v.(map[string]any); ok && (len(path) == 0 || tables[id] || len(sub) > 0) {
+			keys := make([]string, 0, len(sub))
+			for key := range sub {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if err := walk(append(append([]any(nil), path...), key), sub[key]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		// a new value, inserted into the innermost table of the document
+		for k := len(path) - 1; k >= 0; k-- {
+			end, ok := scopes[pathID(path[:k])]
+			if !ok {
+				continue
+			}
+			keys, err := keyText(path[k:])
+			if err != nil {
+				return err
+			}
+			value, err := formatValue(v)
+			if err != nil {
+				return err
+			}
+			if _, exists := originalMap[path[0].(string)]; k == 0 && len(path) > 1 && !exists {
+				header, err := keyText(path[:len(path)-1])
+				if err != nil {
+					return err
+				}
+				if _, ok := newTables[header]; !ok {
+					newTableOrder = append(newTableOrder, header)
+				}
+				key, _ := keyText(path[len(path)-1:])
+				newTables[header] = append(newTables[header], key+" = "+value+"\n")
+				return nil
+			}
+			inserts[end] = append(inserts[end], keys+" = "+value+"\n")
+			return nil
+		}
+		return errors.New("no table for new key")
+	}
+	if err := walk(nil, current); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, line := range inserts[-1] {
+		sb.WriteString(line)
+	}
+	removedScope := false
+	for i, s := range stmts {
+		raw := src[s.start:s.end]
+		switch s.kind {
+		case stmtTable, stmtArrayTable:
+			_, ok := lookup(current, s.path)
+			removedScope = !ok
+			if ok {
+				sb.WriteString(raw)
+			}
+		case stmtKeyValue:
+			nv, ok := lookup(current, s.path)
+			if removedScope || !ok {
+				break
+			}
+			ov, _ := lookup(originalMap, s.path)
+			if reflect.DeepEqual(ov, nv) {
+				sb.WriteString(raw)
+				break
+			}
+			value, err := formatValue(nv)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(src[s.start:s.valueStart] + value + src[s.valueEnd:s.end])
+		default:
+			sb.WriteString(raw)
+		}
+		if len(inserts[i]) > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+		for _, line := range inserts[i] {
+			sb.WriteString(line)
+		}
+	}
+	for _, header := range newTableOrder {
+		block := "[" + header + "]\n" + strings.Join(newTables[header], "")
+		appended = append(appended, block)
+	}
+	for _, block := range appended {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString("\n" + block)
+	}
+	return sb.String(), nil
+}
+
+// nest wraps the value into tables along the path
+func nest(path []any, v any) map[string]any {
+	for i := len(path) - 1; i > 0; i-- {
+		v = map[string]any{fmt.Sprint(path[i]): v}
+	}
+	return map[string]any{fmt.Sprint(path[0]): v}
+}
+
+func pathID(path []any) string {
+	var sb strings.Builder
+	for _, p := range path {
+		if i, ok := p.(int); ok {
+			sb.WriteString("\x00[" + strconv.Itoa(i) + "]")
+			continue
+		}
+		sb.WriteString("\x00" + p.(string))
+	}
+	return sb.String()
+}
+
+// lookup returns the value at the path of string keys and array indexes
+func lookup(v any, path []any) (any, bool) {
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[p]; !ok {
+				return nil, false
+			}
+		case int:
+			s, ok := v.([]any)
+			if !ok || p >= len(s) {
+				return nil, false
+			}
+			v = s[p]
+		}
+	}
+	return v, true
+}
+
+// scan splits the document into statements
+func scan(src string) ([]stmt, error) {
+	s := &scanner{src: src}
+	var stmts []stmt
+	arrayCounts := make(map[string]int)
+	var table []any
+	for s.pos < len(src) {
+		start := s.pos
+		s.skipSpace()
+		switch {
+		case s.pos >= len(src) || s.peek() == '\n' || s.peek() == '\r' || s.peek() == '#':
+			s.skipLine()
+			stmts = append(stmts, stmt{kind: stmtTrivia, start: start, end: s.pos})
+		case strings.HasPrefix(src[s.pos:], "[["):
+			s.pos += 2
+			keys, err := s.keys()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(src[s.pos:], "]]") {
+				return nil, s.errorf("`]]` expected")
+			}
+			s.pos += 2
+			path := s.resolve(keys, arrayCounts)
+			id := pathID(path)
+			table = append(path, arrayCounts[id])
+			arrayCounts[id]++
+			s.skipLine()
+			stmts = append(stmts, stmt{kind: stmtArrayTable, start: start, end: s.pos, path: table})
+		case s.peek() == '[':
+			s.pos++
+			keys, err := s.keys()
+			if err != nil {
+				return nil, err
+			}
+			if s.peek() != ']' {
+				return nil, s.errorf("`]` expected")
+			}
+			s.pos++
+			table = s.resolve(keys, arrayCounts)
+			s.skipLine()
+			stmts = append(stmts, stmt{kind: stmtTable, start: start, end: s.pos, path: table})
+		default:
+			keys, err := s.keys()
+			if err != nil {
+				return nil, err
+			}
+			if s.peek() != '=' {
+				return nil, s.errorf("`=` expected")
+			}
+			s.pos++
+			s.skipSpace()
+			valueStart := s.pos
+			if err := s.value(); err != nil {
+				return nil, err
+			}
+			valueEnd := s.pos
+			s.skipLine()
+			path := append([]any(nil), table...)
+			for _, key := range keys {
+				path = append(path, key)
+			}
+			stmts = append(stmts, stmt{kind: stmtKeyValue, start: start, end: s.pos, path: path, valueStart: valueStart, valueEnd: valueEnd})
+		}
+	}
+	return stmts, nil
+}
+
+type scanner struct {
+	src string
+	pos int
+}
+
+// resolve resolves header keys, prefixes which are arrays of tables refer to their last element
+func (s *scanner) resolve(keys []string, arrayCounts map[string]int) []any {
+	var path []any
+	for i, key := range keys {
+		path = append(path, key)
+		if n, ok := arrayCounts[pathID(path)]; ok && i < len(keys)-1 {
+			path = append(path, n-1)
+		}
+	}
+	return path
+}
+
+func (s *scanner) peek() byte {
+	if s.pos < len(s.src) {
+		return s.src[s.pos]
+	}
+	return 0
+}
+
+func (s *scanner) skipSpace() {
+	for s.pos < len(s.src) && (s.src[s.pos] == ' ' || s.src[s.pos] == '\t') {
+		s.pos++
+	}
+}
+
+// skipLine skips the rest of the line including the newline
+func (s *scanner) skipLine() {
+	for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+		s.pos++
+	}
+	if s.pos < len(s.src) {
+		s.pos++
+	}
+}
+
+// skipTrivia skips whitespace, newlines and comments inside arrays and inline tables
+func (s *scanner) skipTrivia() {
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		case '#':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// keys parses a dotted key
+func (s *scanner) keys() ([]string, error) {
+	var keys []string
+	for {
+		s.skipSpace()
+		switch c := s.peek(); {
+		case c == '"' || c == '\'':
+			start := s.pos
+			if err := s.value(); err != nil {
+				return nil, err
+			}
+			decoded := make(map[string]any)
+			if err := toml2.Unmarshal([]byte("k = "+s.src[start:s.pos]), &decoded); err != nil {
+				return nil, s.errorf("invalid key")
+			}
+			key, _ := decoded["k"].(string)
+			keys = append(keys, key)
+		default:
+			start := s.pos
+			for s.pos < len(s.src) && isBare(s.src[s.pos]) {
+				s.pos++
+			}
+			if s.pos == start {
+				return nil, s.errorf("key expected")
+			}
+			keys = append(keys, s.src[start:s.pos])
+		}
+		s.skipSpace()
+		if s.peek() != '.' {
+			return keys, nil
+		}
+		s.pos++
+	}
+}
+
+// value skips a value
+func (s *scanner) value() error {
+	rest := s.src[s.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		for i := 3; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(rest[i:], `"""`) {
+				i += 3
+				for i < len(rest) && rest[i] == '"' {
+					i++
+				}
+				s.pos += i
+				return nil
+			}
+		}
+		return s.errorf("unterminated string")
+	case strings.HasPrefix(rest, "'''"):
+		end := strings.Index(rest[3:], "'''")
+		if end < 0 {
+			return s.errorf("unterminated string")
+		}
+		i := 3 + end + 3
+		for i < len(rest) && rest[i] == '\'' {
+			i++
+		}
+		s.pos += i
+		return nil
+	case strings.HasPrefix(rest, `"`):
+		for i := 1; i < len(rest) && rest[i] != '\n'; i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if rest[i] == '"' {
+				s.pos += i + 1
+				return nil
+			}
+		}
+		return s.errorf("unterminated string")
+	case strings.HasPrefix(rest, "'"):
+		end := strings.IndexAny(rest[1:], "'\n")
+		if end < 0 || rest[1+end] != '\'' {
+			return s.errorf("unterminated string")
+		}
+		s.pos += end + 2
+		return nil
+	case strings.HasPrefix(rest, "["):
+		s.pos++
+		for {
+			s.skipTrivia()
+			if s.peek() == ']' {
+				s.pos++
+				return nil
+			}
+			if err := s.value(); err != nil {
+				return err
+			}
+			s.skipTrivia()
+			if s.peek() == ',' {
+				s.pos++
+			} else if s.peek() != ']' {
+				return s.errorf("`,` or `]` expected")
+			}
+		}
+	case strings.HasPrefix(rest, "{"):
+		s.pos++
+		for {
+			s.skipSpace()
+			if s.peek() == '}' {
+				s.pos++
+				return nil
+			}
+			if _, err := s.keys(); err != nil {
+				return err
+			}
+			if s.peek() != '=' {
+				return s.errorf("`=` expected")
+			}
+			s.pos++
+			s.skipSpace()
+			if err := s.value(); err != nil {
+				return err
+			}
+			s.skipSpace()
+			if s.peek() == ',' {
+				s.pos++
+			} else if s.peek() != '}' {
+				return s.errorf("`,` or `}` expected")
+			}
+		}
+	default:
+		start := s.pos
+		for s.pos < len(s.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(s.src[s.pos])) {
+			s.pos++
+		}
+		// `1979-05-27 07:32:00` is a single date time
+		if s.pos-start == 10 && s.pos+1 < len(s.src) && s.src[s.pos] == ' ' && s.src[s.pos+1] >= '0' && s.src[s.pos+1] <= '9' {
+			s.pos++
+			for s.pos < len(s.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(s.src[s.pos])) {
+				s.pos++
+			}
+		}
+		if s.pos == start {
+			return s.errorf("value expected")
+		}
+		return nil
+	}
+}
+
+func (s *scanner) errorf(msg string) error {
+	line := strings.Count(s.src[:s.pos], "\n") + 1
+	return errors.New("toml: line " + strconv.Itoa(line) + ": " + msg)
+}
+
+func isBare(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// keyText formats the path as a dotted key
+func keyText(path []any) (string, error) {
+	keys := make([]string, len(path))
+	for i, p := range path {
+		key, ok := p.(string)
+		if !ok {
+			return "", errors.New("array index in new key")
+		}
+		keys[i] = formatKey(key)
+	}
+	return strings.Join(keys, "."), nil
+}
+
+func formatKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isBare(key[i]) {
+			return quote(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// formatValue formats the value as an inline value
+func formatValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float32:
+		return formatFloat(float64(v)), nil
+	case float64:
+		return formatFloat(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case toml2.LocalDate:
+		return v.String(), nil
+	case toml2.LocalTime:
+		return v.String(), nil
+	case toml2.LocalDateTime:
+		return v.String(), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value, err := formatValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			entries = append(entries, formatKey(key)+" = "+value)
+		}
+		if len(entries) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(entries, ", ") + " }", nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elem, err := formatValue(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			elems[i] = elem
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	}
+	return "", errors.New("toml: value of type " + fmt.Sprintf("%T", v) + " cannot be encoded")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote quotes the string as a basic string
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				sb.WriteString(fmt.Sprintf(`\u%04X`, r))
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package toml
+
+import (
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+const testDocument = `# service configuration
+title = "app"   # trailing comment
+
+[server]
+# listen address
+host = "localhost"
+port = 8080
+
+[[rule]]
+name = "a"
+
+[[rule]]
+name = "b"
+`
+
+func TestEncodePreserving(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(m map[string]configer.Field)
+		want   string
+	}{
+		{name: "unchanged", change: func(m map[string]configer.Field) {}, want: testDocument},
+		{name: "changed value keeps comments", change: func(m map[string]configer.Field) {
+			section(m, "server")["port"] = configer.Atof(int64(9090))
+		}, want: `# service configuration
+title = "app"   # trailing comment
+
+[server]
+# listen address
+host = "localhost"
+port = 9090
+
+[[rule]]
+name = "a"
+
+[[rule]]
+name = "b"
+`},
+		{name: "changed value keeps trailing comment", change: func(m map[string]configer.Field) {
+			m["title"] = configer.Atof("api")
+		}, want: `# service configuration
+title = "api"   # trailing comment
+
+[server]
+# listen address
+host = "localhost"
+port = 8080
+
+[[rule]]
+name = "a"
+
+[[rule]]
+name = "b"
+`},
+		{name: "removed and added keys", change: func(m map[string]configer.Field) {
+			server := section(m, "server")
+			delete(server, "host")
+			server["tls"] = configer.Atof(true)
+		}, want: `# service configuration
+title = "app"   # trailing comment
+
+[server]
+# listen address
+port = 8080
+tls = true
+
+[[rule]]
+name = "a"
+
+[[rule]]
+name = "b"
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode([]byte(testDocument))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.change(m)
+			got, err := EncodePreserving([]byte(testDocument), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EncodePreserving() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePreservingFallback(t *testing.T) {
+	m := map[string]configer.Field{"name": configer.Atof("app")}
+	got, err := EncodePreserving([]byte("not = [valid"), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("EncodePreserving() of an invalid testDocument = %q, want Encode() %q", got, want)
+	}
+}
+
+func section(m map[string]configer.Field, key string) map[string]configer.Field {
+	return m[key].Value.(map[string]configer.Field)
+}
@@ -1,6 +1,7 @@
 package toml
 
 import (
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 	toml2 "github.com/pelletier/go-toml/v2"
 )
@@ -21,5 +22,5 @@ func Decode(bytes []byte) (map[string]configer.Field, error) {
 
 // Encode to encode the given config map to toml bytes
 func Encode(configMap map[string]configer.Field) ([]byte, error) {
-	return toml2.Marshal(configMap)
+	return toml2.Marshal(field.ToMap(configMap))
 }
@@ -0,0 +1,49 @@
+package xml
+
+type options struct {
+	attrPrefix string
+	textKey    string
+	localNames bool
+}
+
+// Option option interface for the xml codec
+type Option interface {
+	apply(opts *options)
+}
+
+// WithAttrPrefix with the prefix of attribute keys option, `@` by default, an empty prefix is ignored
+func WithAttrPrefix(prefix string) Option {
+	return attrPrefixOption(prefix)
+}
+
+// WithTextKey with the key of text content option, `#text` by default, an empty key is ignored
+func WithTextKey(key string) Option {
+	return textKeyOption(key)
+}
+
+// WithLocalNames with local names option, namespace prefixes and `xmlns` declarations are dropped from keys
+func WithLocalNames() Option {
+	return localNamesOption(true)
+}
+
+type attrPrefixOption string
+
+func (o attrPrefixOption) apply(opts *options) {
+	if o != "" {
+		opts.attrPrefix = string(o)
+	}
+}
+
+type textKeyOption string
+
+func (o textKeyOption) apply(opts *options) {
+	if o != "" {
+		opts.textKey = string(o)
+	}
+}
+
+type localNamesOption bool
+
+func (o localNamesOption) apply(opts *options) {
+	opts.localNames = bool(o)
+}
@@ -0,0 +1,275 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Codec the xml codec, it maps documents onto config maps by these rules:
+//
+//   - the root element is the single top level key
+//   - an element with attributes or child elements becomes a section
+//   - an element with only text becomes a value, an empty element becomes an empty string
+//   - attributes are keys with the attribute prefix (`@id`)
+//   - text beside attributes or child elements is stored under the text key (`#text`)
+//   - repeated sibling elements become a slice, a single element is never a slice
+//   - text and attribute values are parsed into integers, floats and booleans
+//   - names keep their namespace prefix as written (`soap:Body`) and `xmlns` declarations
+//     are kept as attributes, so documents encode back with the same namespaces,
+//     WithLocalNames drops both
+//
+// Encoding applies the rules in reverse, the config map must have exactly one top level key.
+type Codec struct {
+	opts *options
+}
+
+// NewCodec new xml codec, register it with `encoding.AddSupport` to use custom options
+func NewCodec(opts ...Option) *Codec {
+	options := &options{attrPrefix: "@", textKey: "#text"}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	return &Codec{opts: options}
+}
+
+// element an element being decoded
+type element struct {
+	name     string
+	m        map[string]any
+	text     strings.Builder
+	children bool
+}
+
+// Decode decodes the given xml bytes to the config map
+func (c *Codec) Decode(b []byte) (map[string]configer.Field, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	var stack []*element
+	var root map[string]any
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("xml: " + err.Error())
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil && len(stack) == 0 {
+				return nil, errors.New("xml: more than one root element")
+			}
+			e := &element{name: c.name(t.Name), m: make(map[string]any)}
+			for _, attr := range t.Attr {
+				if c.opts.localNames && (attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				e.m[c.opts.attrPrefix+c.name(attr.Name)] = field.ParseScalar(attr.Value)
+			}
+			if len(stack) > 0 {
+				stack[len(stack)-1].children = true
+			}
+			stack = append(stack, e)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != c.name(t.Name) {
+				return nil, errors.New("xml: unexpected end element `" + c.name(t.Name) + "`")
+			}
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			value := c.value(e)
+			if len(stack) == 0 {
+				root = map[string]any{e.name: value}
+				continue
+			}
+			addChild(stack[len(stack)-1].m, e.name, value)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, errors.New("xml: unclosed element `" + stack[len(stack)-1].name + "`")
+	}
+	configMap := make(map[string]configer.Field, len(root))
+	for key, value := range root {
+		configMap[key] = configer.Atof(value)
+	}
+	return configMap, nil
+}
+
+// value returns the decoded value of the element
+func (c *Codec) value(e *element) any {
+	text := strings.TrimSpace(e.text.String())
+	if len(e.m) == 0 && !e.children {
+		if text == "" {
+			return ""
+		}
+		return field.ParseScalar(text)
+	}
+	if text != "" {
+		e.m[c.opts.textKey] = field.ParseScalar(text)
+	}
+	return e.m
+}
+
+// addChild adds the child value, repeated names turn the value into a slice
+func addChild(m map[string]any, name string, value any) {
+	old, ok := m[name]
+	if !ok {
+		m[name] = value
+		return
+	}
+	switch v := old.(type) {
+	case []any:
+		m[name] = append(v, value)
+	default:
+		m[name] = []any{v, value}
+	}
+}
+
+// name returns the key of the name, `prefix:local` unless local names are used
+func (c *Codec) name(n xml.Name) string {
+	if n.Space == "" || c.opts.localNames {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// Encode encodes the given config map to xml bytes
+func (c *Codec) Encode(m map[string]configer.Field) ([]byte, error) {
+	if len(m) != 1 {
+		return nil, errors.New("xml: exactly one root element expected, got " + strconv.Itoa(len(m)))
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	for name, f := range m {
+		if err := c.writeElement(&buf, name, field.Ftoa(f), 0); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Codec) writeElement(buf *bytes.Buffer, name string, value any, indent int) error {
+	if !validName(name) {
+		return errors.New("xml: `" + name + "` is not a valid element name")
+	}
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString(pad + "<" + name + "/>\n")
+		return nil
+	case map[string]any:
+		return c.writeSection(buf, name, v, indent)
+	case []any:
+		for _, e := range v {
+			if err := c.writeElement(buf, name, e, indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < rv.Len(); i++ {
+			if err := c.writeElement(buf, name, rv.Index(i).Interface(), indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	text := format(value)
+	if text == "" {
+		buf.WriteString(pad + "<" + name + "/>\n")
+		return nil
+	}
+	buf.WriteString(pad + "<" + name + ">")
+	_ = xml.EscapeText(buf, []byte(text))
+	buf.WriteString("</" + name + ">\n")
+	return nil
+}
+
+func (c *Codec) writeSection(buf *bytes.Buffer, name string, m map[string]any, indent int) error {
+	pad := strings.Repeat("  ", indent)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.WriteString(pad + "<" + name)
+	var children []string
+	for _, key := range keys {
+		if !strings.HasPrefix(key, c.opts.attrPrefix) {
+			if key != c.opts.textKey {
+				children = append(children, key)
+			}
+			continue
+		}
+		attr := strings.TrimPrefix(key, c.opts.attrPrefix)
+		if !validName(attr) {
+			return errors.New("xml: `" + attr + "` is not a valid attribute name")
+		}
+		buf.WriteString(" " + attr + "=\"")
+		_ = xml.EscapeText(buf, []byte(format(m[key])))
+		buf.WriteString("\"")
+	}
+	text, hasText := m[c.opts.textKey]
+	switch {
+	case len(children) == 0 && !hasText:
+		buf.WriteString("/>\n")
+		return nil
+	case len(children) == 0:
+		buf.WriteString(">")
+		_ = xml.EscapeText(buf, []byte(format(text)))
+		buf.WriteString("</" + name + ">\n")
+		return nil
+	}
+	buf.WriteString(">\n")
+	if hasText {
+		buf.WriteString(pad + "  ")
+		_ = xml.EscapeText(buf, []byte(format(text)))
+		buf.WriteString("\n")
+	}
+	for _, key := range children {
+		if err := c.writeElement(buf, key, m[key], indent+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(pad + "</" + name + ">\n")
+	return nil
+}
+
+// format formats the scalar value as text
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// validName reports whether the name can be written as an element or attribute name
+func validName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	return !strings.ContainsAny(name, " \t\r\n<>&\"'=/!?")
+}
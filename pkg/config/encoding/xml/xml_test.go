@@ -0,0 +1,137 @@
+package xml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+const soap = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:m="urn:example:stock">
+  <soap:Header>
+    <m:Auth m:mustUnderstand="true">token</m:Auth>
+  </soap:Header>
+  <soap:Body>
+    <m:GetPrice>
+      <m:Item>IBM</m:Item>
+      <m:Item>ACME</m:Item>
+    </m:GetPrice>
+  </soap:Body>
+</soap:Envelope>
+`
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "values and sections", src: "<app><name>api</name><port>8080</port><debug>true</debug><empty/></app>", want: map[string]any{
+			"app": map[string]any{"name": "api", "port": int64(8080), "debug": true, "empty": ""},
+		}},
+		{name: "attributes and text", src: `<server id="1" host="localhost">primary</server>`, want: map[string]any{
+			"server": map[string]any{"@id": int64(1), "@host": "localhost", "#text": "primary"},
+		}},
+		{name: "repeated elements", src: "<list><item>1</item><item>2</item><item>3</item></list>", want: map[string]any{
+			"list": map[string]any{"item": []any{int64(1), int64(2), int64(3)}},
+		}},
+		{name: "custom keys", opts: []Option{WithAttrPrefix("-"), WithTextKey("_")}, src: `<a b="c">d</a>`, want: map[string]any{
+			"a": map[string]any{"-b": "c", "_": "d"},
+		}},
+		{name: "namespaces", src: soap, want: map[string]any{
+			"soap:Envelope": map[string]any{
+				"@xmlns:soap": "http://www.w3.org/2003/05/soap-envelope",
+				"@xmlns:m":    "urn:example:stock",
+				"soap:Header": map[string]any{"m:Auth": map[string]any{"@m:mustUnderstand": true, "#text": "token"}},
+				"soap:Body":   map[string]any{"m:GetPrice": map[string]any{"m:Item": []any{"IBM", "ACME"}}},
+			},
+		}},
+		{name: "default namespace", src: `<config xmlns="urn:example"><a>1</a></config>`, want: map[string]any{
+			"config": map[string]any{"@xmlns": "urn:example", "a": int64(1)},
+		}},
+		{name: "local names", opts: []Option{WithLocalNames()}, src: soap, want: map[string]any{
+			"Envelope": map[string]any{
+				"Header": map[string]any{"Auth": map[string]any{"@mustUnderstand": true, "#text": "token"}},
+				"Body":   map[string]any{"GetPrice": map[string]any{"Item": []any{"IBM", "ACME"}}},
+			},
+		}},
+		{name: "two roots", src: "<a/><b/>", wantErr: true},
+		{name: "mismatched end", src: "<a></b>", wantErr: true},
+		{name: "unclosed", src: "<a><b>", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCodec(tt.opts...).Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m := field.ToMap(got); !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeNamespaces(t *testing.T) {
+	codec := NewCodec()
+	m, err := codec.Decode([]byte(soap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := codec.Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<soap:Envelope xmlns:m="urn:example:stock" xmlns:soap="http://www.w3.org/2003/05/soap-envelope">`,
+		`<m:Auth m:mustUnderstand="true">token</m:Auth>`,
+		"<m:Item>IBM</m:Item>\n      <m:Item>ACME</m:Item>",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Encode() =\n%s\nwant it to contain %s", b, want)
+		}
+	}
+	back, err := codec.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(field.ToMap(back), field.ToMap(m)) {
+		t.Errorf("Decode(Encode()) = %#v, want %#v", field.ToMap(back), field.ToMap(m))
+	}
+}
+
+func TestEncode(t *testing.T) {
+	codec := NewCodec()
+	tests := []struct {
+		name    string
+		m       map[string]configer.Field
+		want    string
+		wantErr bool
+	}{
+		{name: "escaped text", m: map[string]configer.Field{"a": configer.Atof("x < y & z")}, want: "<a>x &lt; y &amp; z</a>\n"},
+		{name: "section", m: map[string]configer.Field{"a": configer.Atof(map[string]any{"@id": 1, "#text": "t", "b": []any{1, 2}})},
+			want: "<a id=\"1\">\n  t\n  <b>1</b>\n  <b>2</b>\n</a>\n"},
+		{name: "two roots", m: map[string]configer.Field{"a": configer.Atof(1), "b": configer.Atof(2)}, wantErr: true},
+		{name: "invalid name", m: map[string]configer.Field{"a b": configer.Atof(1)}, wantErr: true},
+		{name: "invalid attribute", m: map[string]configer.Field{"a": configer.Atof(map[string]any{"@1x": 1})}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.Encode(tt.m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && strings.TrimPrefix(string(got), `<?xml version="1.0" encoding="UTF-8"?>`+"\n") != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package yml
+
+type options struct {
+	profile    string
+	profileKey string
+}
+
+// Option option interface for the yml codec
+type Option interface {
+	apply(opts *options)
+}
+
+// WithProfile with the active profile option, the documents of the profile are merged
+// besides the documents without a profile key, without it profile documents are skipped
+func WithProfile(profile string) Option {
+	return profileOption(profile)
+}
+
+// WithProfileKey with the key naming the profile of a document option, `profile` by default
+func WithProfileKey(key string) Option {
+	return profileKeyOption(key)
+}
+
+type profileOption string
+
+func (o profileOption) apply(opts *options) {
+	opts.profile = string(o)
+}
+
+type profileKeyOption string
+
+func (o profileKeyOption) apply(opts *options) {
+	if o != "" {
+		opts.profileKey = string(o)
+	}
+}
@@ -0,0 +1,379 @@
+package yml
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+	"gopkg.in/yaml.v2"
+)
+
+// EncodePreserving encodes the config map on top of the original document. Comments, key order
+// and formatting are kept, only changed values are rewritten, removed keys are dropped and added
+// keys are appended to their mapping. Documents using anchors or tags, and results which would
+// not decode to the config map, fall back to Encode. A stream of several documents, such as
+// profile documents, can not be written back as one document and is rejected.
+func EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	if docs, err := documents(original); err == nil && len(docs) > 1 {
+		return nil, errors.New("yml: the source has " + strconv.Itoa(len(docs)) + " documents, saving would merge them into one")
+	}
+	current := field.Normalize(field.ToMap(m)).(map[string]any)
+	out, err := patch(string(original), current)
+	if err == nil {
+		decoded := make(map[string]any)
+		if yaml.Unmarshal([]byte(out), &decoded) == nil && reflect.DeepEqual(field.Normalize(decoded), current) {
+			return []byte(out), nil
+		}
+	}
+	return Encode(m)
+}
+
+// entry a `key: value` line of a block mapping and the lines of its value
+type entry struct {
+	key    string
+	indent int
+	// start and end the line range of the entry
+	start int
+	end   int
+	// valueStart and valueEnd the offsets of a single line scalar value in the start line
+	valueStart int
+	valueEnd   int
+	inline     bool
+	// children the entries of a nested block mapping
+	children    []*entry
+	childIndent int
+}
+
+// document the lines of a document
+type document struct {
+	lines    []string
+	override map[int]string
+	deleted  map[int]bool
+	after    map[int][]string
+}
+
+func patch(src string, current map[string]any) (string, error) {
+	original := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(src), &original); err != nil {
+		return "", err
+	}
+	d := &document{
+		lines:    strings.SplitAfter(src, "\n"),
+		override: make(map[int]string),
+		deleted:  make(map[int]bool),
+		after:    make(map[int][]string),
+	}
+	first := true
+	for _, line := range d.lines {
+		content := strings.TrimSpace(line)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if strings.HasPrefix(content, "---") && first {
+			first = false
+			continue
+		}
+		if strings.HasPrefix(content, "---") || strings.HasPrefix(content, "...") || strings.HasPrefix(content, "%") || strings.HasPrefix(line, "\t") {
+			return "", errors.New("unsupported document layout")
+		}
+		first = false
+	}
+	entries, next, err := d.mapping(d.start(), 0, len(d.lines))
+	if err != nil {
+		return "", err
+	}
+	if next != len(d.lines) {
+		return "", errors.New("unsupported document layout")
+	}
+	last := -1
+	for i, line := range d.lines {
+		if content := strings.TrimSpace(line); content != "" && !strings.HasPrefix(content, "#") {
+			last = i
+		}
+	}
+	if err := d.apply(entries, field.Normalize(original).(map[string]any), current, last, 0); err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
+
+// start returns the first line after a leading `---`
+func (d *document) start() int {
+	for i, line := range d.lines {
+		content := strings.TrimSpace(line)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if strings.HasPrefix(content, "---") {
+			return i + 1
+		}
+		return 0
+	}
+	return 0
+}
+
+// mapping parses the block mapping entries with the indent from line i up to end
+func (d *document) mapping(i, indent, end int) ([]*entry, int, error) {
+	var entries []*entry
+	for i < end {
+		line := d.lines[i]
+		content := strings.TrimSpace(line)
+		if content == "" || strings.HasPrefix(content, "#") {
+			i++
+			continue
+		}
+		ind := len(line) - len(strings.TrimLeft(line, " "))
+		if ind < indent {
+			return entries, i, nil
+		}
+		if ind > indent || isItem(content) {
+			return nil, i, errors.New("unexpected indentation")
+		}
+		e, err := d.entry(i, ind, end)
+		if err != nil {
+			return nil, i, err
+		}
+		entries = append(entries, e)
+		i = e.end
+	}
+	return entries, i, nil
+}
+
+// entry parses the mapping entry at line i and the extent of its value
+func (d *document) entry(i, indent, end int) (*entry, error) {
+	line := strings.TrimRight(d.lines[i], "\r\n")
+	key, rest, err := splitKey(line[indent:])
+	if err != nil {
+		return nil, err
+	}
+	value, _ := splitComment(rest)
+	e := &entry{key: key, indent: indent, start: i, end: i + 1}
+	// the value extends over deeper lines and sequence items at the same indentation
+	for j := i + 1; j < end; j++ {
+		next := d.lines[j]
+		content := strings.TrimSpace(next)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		ind := len(next) - len(strings.TrimLeft(next, " "))
+		if ind > indent || ind == indent && value == "" && isItem(content) {
+			e.end = j + 1
+			continue
+		}
+		break
+	}
+	switch {
+	case strings.HasPrefix(value, "&") || strings.HasPrefix(value, "*") || strings.HasPrefix(value, "!"):
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	case value == "" && e.end > i+1:
+		firstChild := ""
+		childIndent := 0
+		for j := i + 1; j < e.end; j++ {
+			content := strings.TrimSpace(d.lines[j])
+			if content != "" && !strings.HasPrefix(content, "#") {
+				firstChild = content
+				childIndent = len(d.lines[j]) - len(strings.TrimLeft(d.lines[j], " "))
+				break
+			}
+		}
+		if isItem(firstChild) {
+			break
+		}
+		children, next, err := d.mapping(i+1, childIndent, e.end)
+		if err != nil {
+			return nil, err
+		}
+		if next != e.end {
+			return nil, errors.New("unexpected indentation")
+		}
+		e.children, e.childIndent = children, childIndent
+	case value != "" && e.end == i+1 && !strings.HasPrefix(value, "|") && !strings.HasPrefix(value, ">"):
+		e.inline = true
+		e.valueStart = len(line) - len(rest) + (len(rest) - len(strings.TrimLeft(rest, " ")))
+		e.valueEnd = e.valueStart + len(value)
+	}
+	return e, nil
+}
+
+// splitKey splits `key: rest` into the key and the rest of the line
+func splitKey(s string) (string, string, error) {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		end := quotedEnd(s)
+		if end < 0 || !strings.HasPrefix(s[end:], ":") {
+			return "", "", errors.New("mapping key expected")
+		}
+		var key string
+		if err := yaml.Unmarshal([]byte(s[:end]), &key); err != nil {
+			return "", "", err
+		}
+		return key, s[end+1:], nil
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			if strings.ContainsAny(s[:i], "{}[]&*!|>") {
+				return "", "", errors.New("unsupported mapping key")
+			}
+			return strings.TrimSpace(s[:i]), s[i+1:], nil
+		}
+		if s[i] == '#' && i > 0 && s[i-1] == ' ' {
+			break
+		}
+	}
+	return "", "", errors.New("mapping key expected")
+}
+
+// splitComment splits the value from a trailing comment
+func splitComment(rest string) (string, string) {
+	s := strings.TrimSpace(rest)
+	if s == "" || s[0] == '#' {
+		return "", s
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		if end := quotedEnd(s); end > 0 {
+			return s[:end], strings.TrimSpace(s[end:])
+		}
+		return s, ""
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return strings.TrimSpace(s[:i]), s[i+1:]
+	}
+	return s, ""
+}
+
+// quotedEnd returns the offset after the closing quote of the quoted scalar at the start of s, -1 if unterminated
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[0] == '"' && s[i] == '\\':
+			i++
+		case s[i] == s[0] && s[0] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == s[0]:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isItem reports whether the line content is a sequence item
+func isItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// apply applies the changes of the mapping, new keys are inserted after line last
+func (d *document) apply(entries []*entry, original, current map[string]any, last int, indent int) error {
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		nv, ok := current[e.key]
+		if !ok {
+			for j := e.start; j < e.end; j++ {
+				d.deleted[j] = true
+			}
+			continue
+		}
+		seen[e.key] = true
+		ov := original[e.key]
+		if reflect.DeepEqual(ov, nv) {
+			continue
+		}
+		om, oIsMap := ov.(map[string]any)
+		nm, nIsMap := nv.(map[string]any)
+		if e.children != nil && oIsMap && nIsMap && len(nm) > 0 {
+			if err := d.apply(e.children, om, nm, e.end-1, e.childIndent); err != nil {
+				return err
+			}
+			continue
+		}
+		if e.inline {
+			if scalar, ok := inlineScalar(nv); ok {
+				line := d.lines[e.start]
+				d.override[e.start] = line[:e.valueStart] + scalar + line[e.valueEnd:]
+				continue
+			}
+		}
+		block, err := marshalEntry(e.key, nv, e.indent)
+		if err != nil {
+			return err
+		}
+		d.override[e.start] = block
+		for j := e.start + 1; j < e.end; j++ {
+			d.deleted[j] = true
+		}
+	}
+	keys := make([]string, 0, len(current))
+	for key := range current {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		block, err := marshalEntry(key, current[key], indent)
+		if err != nil {
+			return err
+		}
+		d.after[last] = append(d.after[last], block)
+	}
+	return nil
+}
+
+// inlineScalar formats a scalar which fits on the line
+func inlineScalar(v any) (string, bool) {
+	switch v.(type) {
+	case map[string]any, []any:
+		return "", false
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	s := strings.TrimSuffix(string(b), "\n")
+	if strings.Contains(s, "\n") {
+		return "", false
+	}
+	return s, true
+}
+
+// marshalEntry marshals `key: value` indented by indent spaces
+func marshalEntry(key string, v any, indent int) (string, error) {
+	b, err := yaml.Marshal(yaml.MapSlice{{Key: key, Value: v}})
+	if err != nil {
+		return "", err
+	}
+	pad := strings.Repeat(" ", indent)
+	lines := strings.SplitAfter(string(b), "\n")
+	var sb strings.Builder
+	for _, line := range lines {
+		if line != "" {
+			sb.WriteString(pad + line)
+		}
+	}
+	return sb.String(), nil
+}
+
+func (d *document) String() string {
+	var sb strings.Builder
+	for _, block := range d.after[-1] {
+		sb.WriteString(block)
+	}
+	for i, line := range d.lines {
+		if override, ok := d.override[i]; ok {
+			sb.WriteString(override)
+		} else if !d.deleted[i] {
+			sb.WriteString(line)
+		}
+		if blocks := d.after[i]; len(blocks) > 0 {
+			if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+				sb.WriteByte('\n')
+			}
+			for _, block := range blocks {
+				sb.WriteString(block)
+			}
+		}
+	}
+	return sb.String()
+}
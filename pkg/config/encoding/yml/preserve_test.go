@@ -0,0 +1,107 @@
+package yml
+
+import (
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+const testDocument = `# service configuration
+title: app  # trailing comment
+
+server:
+  # listen address
+  host: localhost
+  port: 8080
+tags:
+  - a
+  - b
+`
+
+func TestEncodePreserving(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(m map[string]configer.Field)
+		want   string
+	}{
+		{name: "unchanged", change: func(m map[string]configer.Field) {}, want: testDocument},
+		{name: "changed value keeps comments", change: func(m map[string]configer.Field) {
+			section(m, "server")["port"] = configer.Atof(int64(9090))
+			m["title"] = configer.Atof("api")
+		}, want: `# service configuration
+title: api  # trailing comment
+
+server:
+  # listen address
+  host: localhost
+  port: 9090
+tags:
+  - a
+  - b
+`},
+		{name: "removed and added keys", change: func(m map[string]configer.Field) {
+			server := section(m, "server")
+			delete(server, "port")
+			server["tls"] = configer.Atof(true)
+		}, want: `# service configuration
+title: app  # trailing comment
+
+server:
+  # listen address
+  host: localhost
+  tls: true
+tags:
+  - a
+  - b
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Decode([]byte(testDocument))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.change(m)
+			got, err := EncodePreserving([]byte(testDocument), m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EncodePreserving() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePreservingFallback(t *testing.T) {
+	src := "base: &base\n  a: 1\nother: *base\n"
+	m, err := Decode([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := EncodePreserving([]byte(src), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Encode(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("EncodePreserving() of a document with anchors =\n%s\nwant Encode()\n%s", got, want)
+	}
+}
+
+func TestEncodePreservingDocuments(t *testing.T) {
+	m, err := Decode([]byte(profiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := EncodePreserving([]byte(profiles), m); err == nil {
+		t.Errorf("EncodePreserving() of several documents = %s, want an error", got)
+	}
+}
+
+func section(m map[string]configer.Field, key string) map[string]configer.Field {
+	return m[key].Value.(map[string]configer.Field)
+}
@@ -1,24 +1,159 @@
 package yml
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 	"gopkg.in/yaml.v2"
 )
 
+// defaultCodec the codec of the package functions, all documents are merged in order
+var defaultCodec = NewCodec()
+
+// Codec the yml codec.
+//
+// `---` separated documents are merged in order, later documents take precedence. In a stream
+// of several documents, documents with the profile key (`profile: prod`) are profile documents,
+// they are only merged for their profile set with WithProfile, documents without the key are
+// always merged. The key itself is removed. The profile key of a single document is a plain key.
+// Anchors, aliases and `<<` merge keys are resolved and mappings are normalized to string keyed
+// sections. Encoding writes a single document.
+type Codec struct {
+	opts *options
+}
+
+// NewCodec new yml codec, register it with `encoding.AddSupport` to use custom options
+func NewCodec(opts ...Option) *Codec {
+	options := &options{profileKey: "profile"}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	return &Codec{opts: options}
+}
+
 // Decode decodes the given YAML document bytes to the config map.
 func Decode(b []byte) (map[string]configer.Field, error) {
-	decodeMap := make(map[string]any)
-	if err := yaml.Unmarshal(b, &decodeMap); err != nil {
+	return defaultCodec.Decode(b)
+}
+
+// Encode encodes the given config map to YAML document bytes.
+func Encode(m map[string]configer.Field) ([]byte, error) {
+	return yaml.Marshal(field.ToMap(m))
+}
+
+// Decode decodes and merges the documents of the given YAML bytes to the config map
+func (c *Codec) Decode(b []byte) (map[string]configer.Field, error) {
+	docs, err := documents(b)
+	if err != nil {
 		return nil, err
 	}
-	configMap := make(map[string]configer.Field, len(decodeMap))
-	for k, v := range decodeMap {
-		configMap[k] = configer.Atof(v)
+	configMap := make(map[string]configer.Field)
+	for _, doc := range docs {
+		if len(docs) > 1 && !c.selected(doc) {
+			continue
+		}
+		docMap := make(map[string]configer.Field, len(doc))
+		for k, v := range doc {
+			docMap[k] = configer.Atof(v)
+		}
+		field.MergeMap(configMap, docMap)
 	}
 	return configMap, nil
 }
 
-// Encode encodes the given config map to YAML document bytes.
-func Encode(m map[string]configer.Field) ([]byte, error) {
-	return yaml.Marshal(m)
+// documents decodes the non empty documents of the YAML bytes to string keyed maps
+func documents(b []byte) ([]map[string]any, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	var docs []map[string]any
+	for n := 1; ; n++ {
+		var doc any
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		m, ok := stringKeys(doc).(map[string]any)
+		if !ok {
+			return nil, errors.New("yml: document " + strconv.Itoa(n) + " is not a mapping")
+		}
+		docs = append(docs, m)
+	}
+}
+
+// Encode encodes the given config map to a YAML document
+func (c *Codec) Encode(m map[string]configer.Field) ([]byte, error) {
+	return Encode(m)
+}
+
+// EncodePreserving encodes the config map on top of the original document, see EncodePreserving
+func (c *Codec) EncodePreserving(original []byte, m map[string]configer.Field) ([]byte, error) {
+	return EncodePreserving(original, m)
+}
+
+// ForProfile returns a codec with the same options which merges the documents of the profile
+func (c *Codec) ForProfile(profile string) configer.Codec {
+	options := *c.opts
+	options.profile = profile
+	return &Codec{opts: &options}
+}
+
+// selected reports whether the document is merged and removes its profile key
+func (c *Codec) selected(doc map[string]any) bool {
+	profile, ok := doc[c.opts.profileKey]
+	if !ok {
+		return true
+	}
+	delete(doc, c.opts.profileKey)
+	if c.opts.profile == "" {
+		return false
+	}
+	var profiles []string
+	switch p := profile.(type) {
+	case []any:
+		for _, v := range p {
+			profiles = append(profiles, fmt.Sprint(v))
+		}
+	default:
+		profiles = strings.Split(fmt.Sprint(p), ",")
+	}
+	for _, p := range profiles {
+		if strings.TrimSpace(p) == c.opts.profile {
+			return true
+		}
+	}
+	return false
+}
+
+// stringKeys converts `map[interface{}]interface{}` mappings to string keyed maps recursively
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return m
+	case map[string]any:
+		for key, value := range v {
+			v[key] = stringKeys(value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = stringKeys(value)
+		}
+		return v
+	}
+	return v
 }
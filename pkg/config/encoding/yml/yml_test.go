@@ -0,0 +1,92 @@
+package yml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+)
+
+const profiles = `server:
+  port: 8080
+  host: localhost
+---
+profile: dev
+server:
+  host: dev.local
+---
+profile: [prod, staging]
+server:
+  host: prod.example.com
+`
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "documents merged in order", src: "a: 1\nb: {c: 1, d: 1}\n---\nb: {d: 2}\n---\n", want: map[string]any{
+			"a": 1, "b": map[string]any{"c": 1, "d": 2},
+		}},
+		{name: "without profile only documents without profile", src: profiles, want: map[string]any{
+			"server": map[string]any{"port": 8080, "host": "localhost"},
+		}},
+		{name: "profile", opts: []Option{WithProfile("dev")}, src: profiles, want: map[string]any{
+			"server": map[string]any{"port": 8080, "host": "dev.local"},
+		}},
+		{name: "profile list", opts: []Option{WithProfile("staging")}, src: profiles, want: map[string]any{
+			"server": map[string]any{"port": 8080, "host": "prod.example.com"},
+		}},
+		{name: "unknown profile", opts: []Option{WithProfile("test")}, src: profiles, want: map[string]any{
+			"server": map[string]any{"port": 8080, "host": "localhost"},
+		}},
+		{name: "profile key", opts: []Option{WithProfile("dev"), WithProfileKey("env")}, src: "a: 1\n---\nenv: dev, test\na: 2\n", want: map[string]any{
+			"a": 2,
+		}},
+		{name: "single document profile key", src: "profile: dev\na: 1\n", want: map[string]any{
+			"profile": "dev", "a": 1,
+		}},
+		{name: "single document profile key with profile", opts: []Option{WithProfile("prod")}, src: "---\nprofile: dev\na: 1\n", want: map[string]any{
+			"profile": "dev", "a": 1,
+		}},
+		{name: "anchors and merge keys", src: "base: &base\n  host: localhost\n  port: 80\nweb:\n  <<: *base\n  port: 8080\nlist: [*base]\n", want: map[string]any{
+			"base": map[string]any{"host": "localhost", "port": 80},
+			"web":  map[string]any{"host": "localhost", "port": 8080},
+			"list": []any{map[string]any{"host": "localhost", "port": 80}},
+		}},
+		{name: "non string keys", src: "1: one\ntrue: yes\n", want: map[string]any{"1": "one", "true": true}},
+		{name: "not a mapping", src: "- a\n- b\n", wantErr: true},
+		{name: "invalid", src: "a: [1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCodec(tt.opts...).Decode([]byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if m, want := field.Normalize(got), field.Normalize(tt.want); !reflect.DeepEqual(m, want) {
+				t.Errorf("Decode() = %#v, want %#v", m, want)
+			}
+		})
+	}
+}
+
+func TestForProfile(t *testing.T) {
+	codec := NewCodec(WithProfileKey("env"))
+	got, err := codec.ForProfile("dev").Decode([]byte("a: 1\n---\nenv: dev\na: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := field.Normalize(got).(map[string]any)["a"]; a != int64(2) {
+		t.Errorf("a = %v, want the value of the dev document", a)
+	}
+	if codec.opts.profile != "" {
+		t.Error("ForProfile() changed the options of the codec")
+	}
+}
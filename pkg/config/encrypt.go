@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Encryptable is implemented by configurations which support encrypted values
+type Encryptable interface {
+	// EncryptKeys encrypts the values of the keys in place and saves the configuration once
+	EncryptKeys(keys ...string) error
+}
+
+var _ Encryptable = (*config)(nil)
+
+// decryptCache caches the decrypted fields by their key paths and encrypted values
+type decryptCache struct {
+	sync.Mutex
+	fields map[string]configer.Field
+}
+
+// reset drops the decrypted values, it is called whenever the config map is replaced
+func (d *decryptCache) reset() {
+	d.Lock()
+	d.fields = nil
+	d.Unlock()
+}
+
+// EncryptKeys encrypts the values of the keys with the decrypter of the configuration, which must
+// also be a crypt.Encrypter. The values are only decrypted when read, so they are never saved in plaintext.
+//
+// A crypt.KeyEncrypter binds each value to its key path, so a ciphertext copied or moved to another
+// key fails to decrypt, Rename encrypts the moved values again for their new key path.
+// Values of other encrypters are not bound, they decrypt under any key of the document.
+func (c *config) EncryptKeys(keys ...string) error {
+	if _, ok := c.decrypter.(crypt.Encrypter); !ok {
+		return errors.New("config decrypter can not encrypt values")
+	}
+	return c.Update(func(tx *Tx) error {
+		for _, key := range keys {
+			path, err := ParsePath(key)
+			if err != nil {
+				return err
+			}
+			path = tx.resolve(tx.configMap, path)
+			f, ok := getPath(tx.configMap, path)
+			if !ok {
+				return errors.New("config not found for key:`" + key + "`")
+			}
+			if s, ok := f.Value.(string); ok && crypt.IsEncrypted(s) {
+				continue
+			}
+			encrypted, err := encrypt(tx.decrypter, path, f.Value)
+			if err != nil {
+				return errors.New("config key:`" + key + "` " + err.Error())
+			}
+			if err := setPath(tx.configMap, path, configer.Atof(encrypted)); err != nil {
+				return err
+			}
+			tx.dirty = true
+		}
+		return nil
+	})
+}
+
+// decrypt returns the decrypted field of the path if it holds an encrypted value and the configuration
+// has a decrypter, values bound to their key path are decrypted with the document path of the field
+func (c *config) decrypt(path Path, f configer.Field) (configer.Field, error) {
+	s, ok := f.Value.(string)
+	if c.decrypter == nil || !ok || !crypt.IsEncrypted(s) {
+		return f, nil
+	}
+	if len(c.prefix) > 0 {
+		path = append(c.prefix[:len(c.prefix):len(c.prefix)], path...)
+	}
+	key := path.String()
+	c.decrypted.Lock()
+	defer c.decrypted.Unlock()
+	if decrypted, ok := c.decrypted.fields[key+"\x00"+s]; ok {
+		return decrypted, nil
+	}
+	var v any
+	var err error
+	if decrypter, ok := c.decrypter.(crypt.KeyDecrypter); ok {
+		v, err = decrypter.DecryptKey(key, s)
+	} else {
+		v, err = c.decrypter.Decrypt(s)
+	}
+	if err != nil {
+		return configer.Field{}, err
+	}
+	if c.decrypted.fields == nil {
+		c.decrypted.fields = make(map[string]configer.Field)
+	}
+	decrypted := configer.Atof(v)
+	c.decrypted.fields[key+"\x00"+s] = decrypted
+	return decrypted, nil
+}
+
+// encryptValue encrypts the value set on an encrypted field of the path, values which are already encrypted are kept
+func encryptValue(old configer.Field, path Path, value any, decrypter crypt.Decrypter) (any, error) {
+	s, ok := old.Value.(string)
+	if decrypter == nil || !ok || !crypt.IsEncrypted(s) {
+		return value, nil
+	}
+	plain := value
+	if f, ok := value.(configer.Field); ok {
+		plain = f.Value
+	}
+	if s, ok := plain.(string); ok && crypt.IsEncrypted(s) {
+		return value, nil
+	}
+	return encrypt(decrypter, path, plain)
+}
+
+// encrypt encrypts the value of the path, bound to the path if the decrypter is a crypt.KeyEncrypter
+func encrypt(decrypter crypt.Decrypter, path Path, value any) (string, error) {
+	if encrypter, ok := decrypter.(crypt.KeyEncrypter); ok {
+		return encrypter.EncryptKey(path.String(), value)
+	}
+	encrypter, ok := decrypter.(crypt.Encrypter)
+	if !ok {
+		return "", errors.New("config decrypter can not encrypt values")
+	}
+	return encrypter.Encrypt(value)
+}
+
+// rebind encrypts the values under from which are bound to their key path again for their path under to
+func rebind(f configer.Field, from Path, to Path, decrypter crypt.Decrypter) (configer.Field, error) {
+	if decrypter == nil {
+		return f, nil
+	}
+	v, err := rebindValue(f.Value, from, to, decrypter)
+	if err != nil {
+		return configer.Field{}, err
+	}
+	return configer.Field{Type: f.Type, Value: v}, nil
+}
+
+func rebindValue(value any, from Path, to Path, decrypter crypt.Decrypter) (any, error) {
+	switch v := value.(type) {
+	case configer.Field:
+		return rebind(v, from, to, decrypter)
+	case string:
+		if !crypt.IsEncrypted(v) {
+			return v, nil
+		}
+		e, err := crypt.ParseEnvelope(v)
+		if err != nil || !e.BoundKey {
+			return v, nil
+		}
+		keyDecrypter, ok := decrypter.(crypt.KeyDecrypter)
+		if !ok {
+			return nil, errors.New("config decrypter can not decrypt values bound to `" + from.String() + "`")
+		}
+		plain, err := keyDecrypter.DecryptKey(from.String(), v)
+		if err != nil {
+			return nil, errors.New("config key:`" + from.String() + "` " + err.Error())
+		}
+		return encrypt(decrypter, to, plain)
+	case map[string]configer.Field:
+		section := make(map[string]configer.Field, len(v))
+		for key, child := range v {
+			rebound, err := rebind(child, from.Child(key), to.Child(key), decrypter)
+			if err != nil {
+				return nil, err
+			}
+			section[key] = rebound
+		}
+		return section, nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return value, nil
+	}
+	array := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		index := Segment{Index: i, IsIndex: true}
+		rebound, err := rebindValue(rv.Index(i).Interface(), append(from[:len(from):len(from)], index), append(to[:len(to):len(to)], index), decrypter)
+		if err != nil {
+			return nil, err
+		}
+		if rebound == nil {
+			array.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+			continue
+		}
+		array.Index(i).Set(reflect.ValueOf(rebound))
+	}
+	return array.Interface(), nil
+}
+
+func encryptable() (Encryptable, error) {
+	e, ok := L().(Encryptable)
+	if !ok {
+		return nil, errors.New("default config does not support encrypted values")
+	}
+	return e, nil
+}
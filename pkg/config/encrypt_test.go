@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+)
+
+func TestEncryptKeys(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	unbound, err := a.Encrypt("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestConfig(t, "config.toml", "[db]\nuser = \"admin\"\npassword = \"s3cret\"\nport = 5432\nlegacy = \""+unbound+"\"\n", WithDecrypter(a))
+	if err := c.EncryptKeys("db.password", "db.port"); err != nil {
+		t.Fatal(err)
+	}
+	readFile := func() string {
+		b, err := os.ReadFile(c.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if content := readFile(); strings.Contains(content, "s3cret") || !strings.Contains(content, ",aad:key]") {
+		t.Fatalf("file = %q, want values encrypted and bound to their keys", content)
+	}
+	got, err := c.GetString("db.password")
+	if err != nil || got != "s3cret" {
+		t.Fatalf("GetString(db.password) = %q, %v, want s3cret", got, err)
+	}
+
+	tests := []struct {
+		name    string
+		update  func(tx *Tx) error
+		key     string
+		want    any
+		wantErr bool
+	}{
+		{name: "decrypts bound value", key: "db.password", want: "s3cret"},
+		{name: "decrypts bound int", key: "db.port", want: int64(5432)},
+		{name: "decrypts unbound value", key: "db.legacy", want: "legacy"},
+		{
+			name: "ciphertext copied to another key fails",
+			update: func(tx *Tx) error {
+				raw, _ := getPath(tx.configMap, MustParsePath("db.password"))
+				return tx.Set("db.user", raw.Value)
+			},
+			key:     "db.user",
+			wantErr: true,
+		},
+		{
+			name:   "set on encrypted key is encrypted for the key",
+			update: func(tx *Tx) error { return tx.Set("db.password", "changed") },
+			key:    "db.password",
+			want:   "changed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.update != nil {
+				if err := c.Update(tt.update); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := c.Get(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+	if content := readFile(); strings.Contains(content, "changed") {
+		t.Errorf("file = %q, want the value set on an encrypted key encrypted", content)
+	}
+}
+
+func TestRenameEncrypted(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	tests := []struct {
+		name     string
+		from, to string
+		key      string
+	}{
+		{name: "value", from: "db.password", to: "database.password", key: "database.password"},
+		{name: "section", from: "db", to: "database", key: "database.password"},
+		{name: "array", from: "tokens", to: "keys", key: "keys[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.toml", "tokens = [\"a\", \"s3cret\"]\n[db]\npassword = \"s3cret\"\n", WithDecrypter(a))
+			if err := c.EncryptKeys("db.password"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Update(func(tx *Tx) error {
+				bound, err := a.EncryptKey("tokens[1]", "s3cret")
+				if err != nil {
+					return err
+				}
+				return tx.Set("tokens[1]", bound)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Rename(tt.from, tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := c.GetString(tt.key); err != nil || got != "s3cret" {
+				t.Errorf("GetString(%q) = %q, %v, want the renamed value to decrypt", tt.key, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var _ FileSystem = (*ArchiveFileSystem)(nil)
+var _ Stater = (*ArchiveFileSystem)(nil)
+
+// ArchiveFileSystem read-only file system which reads members of tar, tar.gz and zip archives.
+//
+// File paths are either a member of the default archive (`conf/app.toml`) or name the archive
+// and the member separated by `#` (`bundle.tar.gz#conf/app.toml`). URLs use the `tar` or `zip`
+// scheme with the member as fragment (`tar:///opt/app/bundle.tar.gz#conf/app.toml`).
+// Stat reports the archive file itself, so reloading strategies watch the archive.
+type ArchiveFileSystem struct {
+	archive string
+}
+
+// NewArchiveFS new archive file system, path is the default archive and may be empty
+func NewArchiveFS(path string) *ArchiveFileSystem {
+	return &ArchiveFileSystem{archive: path}
+}
+
+// GetReader returns the reader of the archive member
+func (a *ArchiveFileSystem) GetReader(filePath string) (io.Reader, error) {
+	archive, member, err := a.split(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return readMember(archive, member)
+}
+
+// GetReaderFromURL returns the reader of the archive member from a `tar`, `zip` or `file` URL
+func (a *ArchiveFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
+	switch url.Scheme {
+	case "tar", "zip", "file", "":
+	default:
+		return nil, errors.New("archive URL scheme `" + url.Scheme + "` not support")
+	}
+	if url.Fragment == "" {
+		return a.GetReader(url.Path)
+	}
+	return readMember(url.Path, cleanPath(url.Fragment))
+}
+
+// GetWriter always returns ErrReadOnly
+func (a *ArchiveFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromURL always returns ErrReadOnly
+func (a *ArchiveFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromPath always returns ErrReadOnly
+func (a *ArchiveFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// Stat returns the file info of the archive containing the file
+func (a *ArchiveFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	archive, _, err := a.split(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(archive)
+}
+
+// GetPath returns the file path
+func (a *ArchiveFileSystem) GetPath(file *os.File, url *url.URL, basePath string, fileName string) string {
+	if url != nil {
+		if url.Fragment != "" {
+			return url.Path + "#" + url.Fragment
+		}
+		return url.Path
+	}
+	if file != nil {
+		return file.Name()
+	}
+	return path.Join(basePath, fileName)
+}
+
+// GetBasePath returns the base path of the archive member
+func (a *ArchiveFileSystem) GetBasePath(filePath string) string {
+	archive, member, err := a.split(filePath)
+	if err != nil {
+		return filePath
+	}
+	return archive + "#" + path.Dir(member)
+}
+
+// GetFileName returns the file name
+func (a *ArchiveFileSystem) GetFileName(filePath string) string {
+	base := path.Base(filepath.ToSlash(filePath))
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// LocateFromURL returns the archive member URL
+func (a *ArchiveFileSystem) LocateFromURL(basePath string, fileName string) *url.URL {
+	archive, member, err := a.split(basePath)
+	if err != nil {
+		return nil
+	}
+	scheme := "tar"
+	if strings.EqualFold(filepath.Ext(archive), ".zip") {
+		scheme = "zip"
+	}
+	return &url.URL{Scheme: scheme, Path: archive, Fragment: cleanPath(path.Join(member, fileName))}
+}
+
+// GetURL return the archive member URL
+func (a *ArchiveFileSystem) GetURL(basePath string, fileName string) *url.URL {
+	return a.LocateFromURL(basePath, fileName)
+}
+
+// split splits the file path, or a `tar`, `zip` or `file` URL, into the archive path and the member name
+func (a *ArchiveFileSystem) split(filePath string) (string, string, error) {
+	if u, err := url.Parse(filePath); err == nil && (u.Scheme == "tar" || u.Scheme == "zip" || u.Scheme == "file") {
+		if u.Fragment == "" {
+			return a.split(u.Path)
+		}
+		return u.Path, cleanPath(u.Fragment), nil
+	}
+	if index := strings.LastIndex(filePath, "#"); index >= 0 {
+		return filePath[:index], cleanPath(filePath[index+1:]), nil
+	}
+	if a.archive == "" {
+		return "", "", errors.New("archive not found for path `" + filePath + "`")
+	}
+	return a.archive, cleanPath(filePath), nil
+}
+
+// readMember reads the member of the archive, the archive format is detected by its content
+func readMember(archive, member string) (io.Reader, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	br := bufio.NewReader(file)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return readZipMember(file, member)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTarMember(gz, archive, member)
+	default:
+		return readTarMember(br, archive, member)
+	}
+}
+
+func readTarMember(r io.Reader, archive, member string) (io.Reader, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, &fs.PathError{Op: "open", Path: archive + "#" + member, Err: fs.ErrNotExist}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !header.FileInfo().Mode().IsRegular() || cleanPath(header.Name) != member {
+			continue
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	}
+}
+
+func readZipMember(file *os.File, member string) (io.Reader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || cleanPath(f.Name) != member {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(b), nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: file.Name() + "#" + member, Err: fs.ErrNotExist}
+}
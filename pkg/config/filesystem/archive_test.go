@@ -0,0 +1,115 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var archiveMembers = map[string]string{
+	"conf/app.toml": "name = \"app\"",
+	"README":        "readme",
+}
+
+func writeTar(t *testing.T, compress bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for name, content := range archiveMembers {
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func writeZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range archiveMembers {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveFS(t *testing.T) {
+	tests := []struct {
+		name string
+		data func(t *testing.T) []byte
+	}{
+		{name: "bundle.tar", data: func(t *testing.T) []byte { return writeTar(t, false) }},
+		{name: "bundle.tar.gz", data: func(t *testing.T) []byte { return writeTar(t, true) }},
+		{name: "bundle.zip", data: writeZip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(archive, tt.data(t), 0644); err != nil {
+				t.Fatal(err)
+			}
+			a := NewArchiveFS(archive)
+			r, err := a.GetReader("conf/app.toml")
+			if got := readAll(t, r, err); got != archiveMembers["conf/app.toml"] {
+				t.Errorf("GetReader() = %q, want %q", got, archiveMembers["conf/app.toml"])
+			}
+			r, err = NewArchiveFS("").GetReader(archive + "#README")
+			if got := readAll(t, r, err); got != "readme" {
+				t.Errorf("GetReader(archive#member) = %q, want readme", got)
+			}
+			r, err = a.GetReaderFromURL(&url.URL{Scheme: "tar", Path: archive, Fragment: "/conf/app.toml"})
+			if got := readAll(t, r, err); got != archiveMembers["conf/app.toml"] {
+				t.Errorf("GetReaderFromURL() = %q, want %q", got, archiveMembers["conf/app.toml"])
+			}
+			if _, err := a.GetReader("conf/missing.toml"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("GetReader() of a missing member error = %v, want fs.ErrNotExist", err)
+			}
+			source := (&url.URL{Scheme: "tar", Path: archive, Fragment: "conf/app.toml"}).String()
+			r, err = NewArchiveFS("").GetReader(source)
+			if got := readAll(t, r, err); got != archiveMembers["conf/app.toml"] {
+				t.Errorf("GetReader(%q) = %q, want %q", source, got, archiveMembers["conf/app.toml"])
+			}
+			if info, err := NewArchiveFS("").Stat(source); err != nil || info.Name() != tt.name {
+				t.Errorf("Stat(%q) = %v, %v, want the archive", source, info, err)
+			}
+			if _, err := a.GetWriterFromURL(&url.URL{Path: archive}); err != ErrReadOnly {
+				t.Errorf("GetWriterFromURL() error = %v, want ErrReadOnly", err)
+			}
+		})
+	}
+	if _, err := NewArchiveFS("").GetReader("conf/app.toml"); err == nil {
+		t.Error("GetReader() without an archive succeeded")
+	}
+}
@@ -2,14 +2,19 @@ package filesystem
 
 import (
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 // ConfigFileSystem config file system struct
 type ConfigFileSystem struct {
 	opts *options
+	// client the HTTP client for http and https URLs, created on first use
+	clientOnce sync.Once
+	client     *http.Client
 }
 
 // DefaultFileSystem default file system
@@ -33,12 +38,16 @@ func NewFileSystem(opts ...Option) *ConfigFileSystem {
 
 // GetReader returns the reader
 func (fs *ConfigFileSystem) GetReader(filePath string) (io.Reader, error) {
-	return os.Open(filePath)
+	return os.Open(fs.resolve(filePath))
 }
 
 // GetReaderFromURL returns the reader from URL
 func (fs *ConfigFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
-	return os.Open(url.Path)
+	switch url.Scheme {
+	case "http", "https":
+		return fs.getHTTP(url)
+	}
+	return os.Open(fs.resolve(url.Path))
 }
 
 // GetWriter returns the writer
@@ -46,9 +55,43 @@ func (fs *ConfigFileSystem) GetWriter(file *os.File) (io.Writer, error) {
 	return file, nil
 }
 
+// GetWriterFromPath returns the writer from file path
+func (fs *ConfigFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	filePath = fs.resolve(filePath)
+	if fs.opts.root != "" {
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return nil, err
+		}
+	}
+	if fs.opts.versioning <= 0 {
+		return os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+	if err := fs.recordInitial(filePath); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &versionedFile{File: file, fs: fs, path: filePath}, nil
+}
+
 // GetWriterFromURL returns the writer from URL
 func (fs *ConfigFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
-	return os.Create(url.Path)
+	return fs.GetWriterFromPath(url.Path)
+}
+
+// Stat returns the file info
+func (fs *ConfigFileSystem) Stat(filePath string) (os.FileInfo, error) {
+	return os.Stat(fs.resolve(filePath))
+}
+
+// resolve returns the file path joined with the root directory
+func (fs *ConfigFileSystem) resolve(filePath string) string {
+	if fs.opts.root == "" {
+		return filePath
+	}
+	return filepath.Join(fs.opts.root, filePath)
 }
 
 // GetPath returns the file path
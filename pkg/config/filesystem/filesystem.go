@@ -2,6 +2,7 @@ package filesystem
 
 import (
 	"io"
+	"io/fs"
 	"net/url"
 	"os"
 )
@@ -27,3 +28,29 @@ type FileSystem interface {
 	// GetURL get file URL
 	GetURL(basePath string, fileName string) *url.URL
 }
+
+// PathWriter is implemented by file systems which open writers by file path,
+// writers which implement io.Closer are closed after writing
+type PathWriter interface {
+	// GetWriterFromPath get file writer from file path, the file is truncated
+	GetWriterFromPath(filePath string) (io.Writer, error)
+}
+
+// Stater is implemented by file systems which report file infos, reloading strategies use it to detect changes
+type Stater interface {
+	// Stat get file info
+	Stat(filePath string) (fs.FileInfo, error)
+}
+
+// Revisioner is implemented by file systems which version files, reloading strategies use it to detect changes
+type Revisioner interface {
+	// Revision get the current revision of the file
+	Revision(filePath string) (string, error)
+}
+
+// LoadedRevisioner is implemented by file systems which read a consistent snapshot together with its
+// revision, reloading strategies record the loaded revision so a change racing the reload is not missed
+type LoadedRevisioner interface {
+	// LoadedRevision get the revision of the last loaded snapshot, false if nothing has been loaded
+	LoadedRevision(filePath string) (string, bool)
+}
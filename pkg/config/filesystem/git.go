@@ -0,0 +1,347 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ FileSystem = (*GitFileSystem)(nil)
+var _ PathWriter = (*GitFileSystem)(nil)
+var _ Stater = (*GitFileSystem)(nil)
+var _ Revisioner = (*GitFileSystem)(nil)
+var _ LoadedRevisioner = (*GitFileSystem)(nil)
+
+// GitFileSystem file system which reads files at a branch, tag or commit of a local
+// bare or working repository through the `git` command, no network is needed.
+//
+// File paths are relative to the repository root. URLs use the `git` scheme with the
+// repository as path, the file as fragment and an optional ref query
+// (`git:///srv/config.git?ref=v1.2.0#conf/app.toml`).
+// Writes create a commit on the branch with the author taken from WithCurrentUser,
+// the working tree of a checked out branch is not updated.
+type GitFileSystem struct {
+	repo string
+	ref  string
+	// refErr the error of validating the ref, checked once
+	refErr error
+	opts   *options
+	mu     sync.Mutex
+	// loaded the commit of the last read at the ref
+	loaded string
+}
+
+// NewGitFS new git file system reading the repository at the given ref, HEAD if ref is empty
+func NewGitFS(repo string, ref string, opts ...Option) *GitFileSystem {
+	options := &options{}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	g := &GitFileSystem{repo: repo, ref: ref, opts: options}
+	g.refErr = g.checkRef(ref)
+	return g
+}
+
+// GetReader returns the reader of the file at the ref
+func (g *GitFileSystem) GetReader(filePath string) (io.Reader, error) {
+	return g.read(g.repo, g.ref, filePath)
+}
+
+// GetReaderFromURL returns the reader of the file from a `git` URL
+func (g *GitFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
+	repo, ref, filePath, err := g.parseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return g.read(repo, ref, filePath)
+}
+
+// GetWriter returns the writer which commits the file with the same name on Close
+func (g *GitFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return g.GetWriterFromPath(file.Name())
+}
+
+// GetWriterFromURL returns the writer which commits the file of the URL on Close
+func (g *GitFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	repo, ref, filePath, err := g.parseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &gitWriter{fs: g, repo: repo, ref: ref, path: filePath}, nil
+}
+
+// GetWriterFromPath returns the writer which commits the file on Close
+func (g *GitFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	return &gitWriter{fs: g, repo: g.repo, ref: g.ref, path: cleanPath(filePath)}, nil
+}
+
+// Stat returns the file info, the modification time is the commit time of the ref
+func (g *GitFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	commit, err := g.Revision(filePath)
+	if err != nil {
+		return nil, err
+	}
+	name := cleanPath(filePath)
+	size, err := g.gitString(g.repo, nil, nil, "cat-file", "-s", commit+":"+name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: filePath, Err: fs.ErrNotExist}
+	}
+	committed, err := g.gitString(g.repo, nil, nil, "log", "-1", "--format=%ct", commit)
+	if err != nil {
+		return nil, err
+	}
+	sizeN, _ := strconv.ParseInt(size, 10, 64)
+	unix, _ := strconv.ParseInt(committed, 10, 64)
+	return gitFileInfo{name: path.Base(name), size: sizeN, modTime: time.Unix(unix, 0), commit: commit}, nil
+}
+
+// Revision returns the commit the ref currently points to
+func (g *GitFileSystem) Revision(filePath string) (string, error) {
+	return g.revParse(g.repo, g.ref)
+}
+
+// LoadedRevision returns the commit of the last read at the ref
+func (g *GitFileSystem) LoadedRevision(filePath string) (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.loaded, g.loaded != ""
+}
+
+// GetPath returns the file path
+func (g *GitFileSystem) GetPath(file *os.File, url *url.URL, basePath string, fileName string) string {
+	if url != nil {
+		return url.Fragment
+	}
+	if file != nil {
+		return file.Name()
+	}
+	return path.Join(basePath, fileName)
+}
+
+// GetBasePath returns the base file path
+func (g *GitFileSystem) GetBasePath(filePath string) string {
+	return path.Dir(cleanPath(filePath))
+}
+
+// GetFileName returns the file name
+func (g *GitFileSystem) GetFileName(filePath string) string {
+	base := path.Base(filepath.ToSlash(filePath))
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// LocateFromURL returns the `git` URL of the file
+func (g *GitFileSystem) LocateFromURL(basePath string, fileName string) *url.URL {
+	return &url.URL{
+		Scheme:   "git",
+		Path:     g.repo,
+		RawQuery: url.Values{"ref": []string{g.ref}}.Encode(),
+		Fragment: cleanPath(path.Join(basePath, fileName)),
+	}
+}
+
+// GetURL return the `git` URL of the file
+func (g *GitFileSystem) GetURL(basePath string, fileName string) *url.URL {
+	return g.LocateFromURL(basePath, fileName)
+}
+
+func (g *GitFileSystem) parseURL(u *url.URL) (string, string, string, error) {
+	if u.Scheme != "git" && u.Scheme != "" {
+		return "", "", "", errors.New("git URL scheme `" + u.Scheme + "` not support")
+	}
+	repo := u.Path
+	if repo == "" {
+		repo = g.repo
+	}
+	ref := u.Query().Get("ref")
+	if ref == "" {
+		ref = g.ref
+	}
+	if err := g.validRef(ref); err != nil {
+		return "", "", "", err
+	}
+	if u.Fragment == "" {
+		return "", "", "", errors.New("git URL `" + u.String() + "` has no file fragment")
+	}
+	return repo, ref, cleanPath(u.Fragment), nil
+}
+
+func (g *GitFileSystem) read(repo, ref, filePath string) (io.Reader, error) {
+	name := cleanPath(filePath)
+	commit, err := g.revParse(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	b, err := g.git(repo, nil, nil, "cat-file", "blob", commit+":"+name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: filePath, Err: fs.ErrNotExist}
+	}
+	if repo == g.repo && ref == g.ref {
+		g.mu.Lock()
+		g.loaded = commit
+		g.mu.Unlock()
+	}
+	return bytes.NewReader(b), nil
+}
+
+// commit commits the file content on top of the branch without touching a working tree
+func (g *GitFileSystem) commit(repo, ref, filePath string, data []byte) error {
+	if err := g.validRef(ref); err != nil {
+		return err
+	}
+	branch := ref
+	if branch == "HEAD" {
+		head, err := g.gitString(repo, nil, nil, "symbolic-ref", "HEAD")
+		if err != nil {
+			return errors.New("git HEAD is detached, commit requires a branch")
+		}
+		branch = head
+	} else if !strings.HasPrefix(branch, "refs/") {
+		branch = "refs/heads/" + branch
+	}
+	if !strings.HasPrefix(branch, "refs/heads/") {
+		return errors.New("git ref `" + ref + "` is not a branch")
+	}
+	parent, _ := g.gitString(repo, nil, nil, "rev-parse", "--verify", "-q", "--end-of-options", branch+"^{commit}")
+	blob, err := g.gitString(repo, data, nil, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return err
+	}
+	indexDir, err := os.MkdirTemp("", "ridi-config-git-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(indexDir)
+	env := append(g.authorEnv(), "GIT_INDEX_FILE="+filepath.Join(indexDir, "index"))
+	if parent != "" {
+		if _, err := g.gitString(repo, nil, env, "read-tree", parent); err != nil {
+			return err
+		}
+	}
+	if _, err := g.gitString(repo, nil, env, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+filePath); err != nil {
+		return err
+	}
+	tree, err := g.gitString(repo, nil, env, "write-tree")
+	if err != nil {
+		return err
+	}
+	args := []string{"commit-tree", tree, "-m", "Update " + filePath}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	commit, err := g.gitString(repo, nil, env, args...)
+	if err != nil {
+		return err
+	}
+	_, err = g.gitString(repo, nil, nil, "update-ref", branch, commit, parent)
+	return err
+}
+
+// revParse returns the commit the ref points to
+func (g *GitFileSystem) revParse(repo, ref string) (string, error) {
+	if err := g.validRef(ref); err != nil {
+		return "", err
+	}
+	return g.gitString(repo, nil, nil, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+}
+
+// validRef returns the error of the ref, the ref of the file system was checked by NewGitFS
+func (g *GitFileSystem) validRef(ref string) error {
+	if ref == g.ref {
+		return g.refErr
+	}
+	return g.checkRef(ref)
+}
+
+// checkRef rejects refs which git could take as options and refs which are not valid ref names,
+// branches, tags, full ref names, HEAD and commit ids are accepted
+func (g *GitFileSystem) checkRef(ref string) error {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return errors.New("git ref `" + ref + "` is invalid")
+	}
+	if _, err := g.gitString("", nil, nil, "check-ref-format", "--allow-onelevel", ref); err != nil {
+		return errors.New("git ref `" + ref + "` is invalid")
+	}
+	return nil
+}
+
+// authorEnv returns the author and committer environment from the current user option, `Name <email>`
+func (g *GitFileSystem) authorEnv() []string {
+	user := strings.TrimSpace(g.opts.currentUser)
+	if user == "" {
+		user = "ridi-config"
+	}
+	name, email := user, ""
+	if start, end := strings.Index(user, "<"), strings.LastIndex(user, ">"); start >= 0 && end > start {
+		name, email = strings.TrimSpace(user[:start]), user[start+1:end]
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email,
+	}
+}
+
+// git runs the git command in the repository and returns its output
+func (g *GitFileSystem) git(repo string, stdin []byte, env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New("git " + args[0] + ": " + strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// gitString runs the git command in the repository and returns its trimmed output
+func (g *GitFileSystem) gitString(repo string, stdin []byte, env []string, args ...string) (string, error) {
+	out, err := g.git(repo, stdin, env, args...)
+	return string(bytes.TrimSpace(out)), err
+}
+
+// gitWriter buffers the file content and commits it on Close
+type gitWriter struct {
+	fs   *GitFileSystem
+	repo string
+	ref  string
+	path string
+	buf  bytes.Buffer
+}
+
+func (w *gitWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close commits the written content
+func (w *gitWriter) Close() error {
+	return w.fs.commit(w.repo, w.ref, w.path, w.buf.Bytes())
+}
+
+type gitFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	commit  string
+}
+
+func (i gitFileInfo) Name() string       { return i.name }
+func (i gitFileInfo) Size() int64        { return i.size }
+func (i gitFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i gitFileInfo) ModTime() time.Time { return i.modTime }
+func (i gitFileInfo) IsDir() bool        { return false }
+func (i gitFileInfo) Sys() any           { return i.commit }
@@ -0,0 +1,139 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// newGitRepo creates an empty repository on branch main, the test is skipped without git
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "--bare", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %s", out)
+	}
+	if out, err := exec.Command("git", "-C", repo, "symbolic-ref", "HEAD", "refs/heads/main").CombinedOutput(); err != nil {
+		t.Fatalf("git symbolic-ref: %s", out)
+	}
+	return repo
+}
+
+func gitWrite(t *testing.T, w io.Writer, err error, content string) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitFS(t *testing.T) {
+	repo := newGitRepo(t)
+	g := NewGitFS(repo, "", WithCurrentUser("Config Bot <bot@example.com>"))
+	if _, ok := g.LoadedRevision("conf/app.toml"); ok {
+		t.Error("LoadedRevision() before a read reported a revision")
+	}
+	w, err := g.GetWriterFromPath("conf/app.toml")
+	gitWrite(t, w, err, "v1")
+	first, err := g.Revision("conf/app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = g.GetWriterFromPath("conf/app.toml")
+	gitWrite(t, w, err, "v2")
+	second, err := g.Revision("conf/app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("Revision() did not change after a commit")
+	}
+	r, err := g.GetReader("conf/app.toml")
+	if got := readAll(t, r, err); got != "v2" {
+		t.Errorf("GetReader() = %q, want v2", got)
+	}
+	r, err = g.GetReaderFromURL(&url.URL{Scheme: "git", Path: repo, RawQuery: "ref=" + first, Fragment: "conf/app.toml"})
+	if got := readAll(t, r, err); got != "v1" {
+		t.Errorf("GetReaderFromURL() at the first commit = %q, want v1", got)
+	}
+	if loaded, ok := g.LoadedRevision("conf/app.toml"); !ok || loaded != second {
+		t.Errorf("LoadedRevision() = %q, %v, want the second commit read at the ref", loaded, ok)
+	}
+	info, err := g.Stat("conf/app.toml")
+	if err != nil || info.Size() != 2 || info.Sys() != second {
+		t.Errorf("Stat() = %v, %v, want 2 bytes at the second commit", info, err)
+	}
+	if _, err := g.GetReader("conf/missing.toml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetReader() of a missing file error = %v, want fs.ErrNotExist", err)
+	}
+	author, err := g.gitString(repo, nil, nil, "log", "-1", "--format=%an <%ae>", "main")
+	if err != nil || author != "Config Bot <bot@example.com>" {
+		t.Errorf("commit author = %q, %v, want the current user", author, err)
+	}
+}
+
+func TestGitFSRef(t *testing.T) {
+	repo := newGitRepo(t)
+	g := NewGitFS(repo, "main")
+	w, err := g.GetWriterFromPath("app.toml")
+	gitWrite(t, w, err, "content")
+	commit, err := g.Revision("app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.gitString(repo, nil, nil, "tag", "v1.0.0", commit); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ref     string
+		wantErr bool
+	}{
+		{ref: "main"},
+		{ref: "HEAD"},
+		{ref: "refs/heads/main"},
+		{ref: "v1.0.0"},
+		{ref: commit},
+		{ref: "missing", wantErr: true},
+		{ref: "--output=/tmp/leak", wantErr: true},
+		{ref: "-h", wantErr: true},
+		{ref: "main~1", wantErr: true},
+		{ref: "main..HEAD", wantErr: true},
+		{ref: "@{-1}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			u := &url.URL{Scheme: "git", Path: repo, RawQuery: url.Values{"ref": []string{tt.ref}}.Encode(), Fragment: "app.toml"}
+			r, err := g.GetReaderFromURL(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetReaderFromURL(ref=%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if err != nil {
+				if strings.HasPrefix(tt.ref, "-") && !strings.Contains(err.Error(), "is invalid") {
+					t.Errorf("GetReaderFromURL(ref=%q) error = %v, want invalid ref", tt.ref, err)
+				}
+				return
+			}
+			if got := readAll(t, r, nil); got != "content" {
+				t.Errorf("GetReaderFromURL(ref=%q) = %q, want content", tt.ref, got)
+			}
+		})
+	}
+	if _, err := NewGitFS(repo, "-h").Revision("app.toml"); err == nil {
+		t.Error("Revision() of an option-like ref succeeded")
+	}
+	if err := g.commit(repo, "refs/tags/v1.0.0", "app.toml", []byte("x")); err == nil {
+		t.Error("commit() on a tag succeeded")
+	}
+}
@@ -0,0 +1,72 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ContentTyper is implemented by readers which know the media type of the content,
+// such as the readers of HTTP responses
+type ContentTyper interface {
+	// ContentType get the media type of the content, empty if unknown
+	ContentType() string
+}
+
+// httpReader the body of an HTTP response
+type httpReader struct {
+	*bytes.Reader
+	contentType string
+}
+
+func (r *httpReader) ContentType() string {
+	return r.contentType
+}
+
+// getHTTP reads the body of the URL, the reader implements ContentTyper
+func (fs *ConfigFileSystem) getHTTP(u *url.URL) (io.Reader, error) {
+	resp, err := fs.httpClient().Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("get " + u.Redacted() + ": " + resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &httpReader{Reader: bytes.NewReader(body), contentType: resp.Header.Get("Content-Type")}, nil
+}
+
+// httpClient returns the HTTP client configured with the proxy and connection options
+func (fs *ConfigFileSystem) httpClient() *http.Client {
+	fs.clientOnce.Do(func() {
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        fs.opts.maxTotalConnections,
+			MaxIdleConnsPerHost: fs.opts.maxHostConnections,
+			MaxConnsPerHost:     fs.opts.maxHostConnections,
+			IdleConnTimeout:     90 * time.Second,
+		}
+		if fs.opts.proxyHost != "" {
+			host := fs.opts.proxyHost
+			if fs.opts.proxyPort > 0 {
+				host = net.JoinHostPort(host, strconv.Itoa(fs.opts.proxyPort))
+			}
+			transport.Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: host})
+		}
+		fs.client = &http.Client{Transport: transport, Timeout: time.Minute}
+	})
+	return fs.client
+}
@@ -0,0 +1,89 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+var _ FileSystem = (*IOFileSystem)(nil)
+var _ Stater = (*IOFileSystem)(nil)
+
+// ErrReadOnly is returned when writing to a read-only file system
+var ErrReadOnly = errors.New("file system is read-only")
+
+// IOFileSystem read-only file system backed by an io/fs.FS, e.g. an embed.FS
+type IOFileSystem struct {
+	fsys fs.FS
+}
+
+// FromFS new read-only file system backed by the given fs.FS
+func FromFS(fsys fs.FS) *IOFileSystem {
+	return &IOFileSystem{fsys: fsys}
+}
+
+// GetReader returns the reader
+func (f *IOFileSystem) GetReader(filePath string) (io.Reader, error) {
+	return f.fsys.Open(cleanPath(filePath))
+}
+
+// GetReaderFromURL returns the reader from URL
+func (f *IOFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
+	return f.GetReader(url.Path)
+}
+
+// GetWriter always returns ErrReadOnly
+func (f *IOFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromURL always returns ErrReadOnly
+func (f *IOFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromPath always returns ErrReadOnly
+func (f *IOFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// Stat returns the file info
+func (f *IOFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, cleanPath(filePath))
+}
+
+// GetPath returns the file path
+func (f *IOFileSystem) GetPath(file *os.File, url *url.URL, basePath string, fileName string) string {
+	if url != nil {
+		return url.Path
+	}
+	if file != nil {
+		return file.Name()
+	}
+	return path.Join(basePath, fileName)
+}
+
+// GetBasePath returns the base file path
+func (f *IOFileSystem) GetBasePath(filePath string) string {
+	return path.Dir(cleanPath(filePath))
+}
+
+// GetFileName returns the file name
+func (f *IOFileSystem) GetFileName(filePath string) string {
+	base := path.Base(filepath.ToSlash(filePath))
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// LocateFromURL returns the file URL
+func (f *IOFileSystem) LocateFromURL(basePath string, fileName string) *url.URL {
+	return &url.URL{Scheme: "fs", Path: cleanPath(path.Join(basePath, fileName))}
+}
+
+// GetURL return the file URL
+func (f *IOFileSystem) GetURL(basePath string, fileName string) *url.URL {
+	return f.LocateFromURL(basePath, fileName)
+}
@@ -0,0 +1,173 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var _ FileSystem = (*MemFileSystem)(nil)
+var _ PathWriter = (*MemFileSystem)(nil)
+var _ Stater = (*MemFileSystem)(nil)
+
+// MemFileSystem map backed in-memory file system, useful for tests
+type MemFileSystem struct {
+	mu    sync.RWMutex
+	files map[string]*memFile
+}
+
+type memFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemFS new in-memory file system
+func NewMemFS() *MemFileSystem {
+	return &MemFileSystem{files: make(map[string]*memFile)}
+}
+
+// WriteFile writes data to the named file, creating it if necessary
+func (m *MemFileSystem) WriteFile(filePath string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[cleanPath(filePath)] = &memFile{data: append([]byte(nil), data...), modTime: time.Now()}
+}
+
+// ReadFile returns the content of the named file
+func (m *MemFileSystem) ReadFile(filePath string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.files[cleanPath(filePath)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: filePath, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+// Remove removes the named file
+func (m *MemFileSystem) Remove(filePath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, cleanPath(filePath))
+}
+
+// GetReader returns the reader
+func (m *MemFileSystem) GetReader(filePath string) (io.Reader, error) {
+	data, err := m.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// GetReaderFromURL returns the reader from URL
+func (m *MemFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
+	return m.GetReader(url.Path)
+}
+
+// GetWriter returns the writer of the in-memory file with the same name
+func (m *MemFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return m.GetWriterFromPath(file.Name())
+}
+
+// GetWriterFromURL returns the writer from URL
+func (m *MemFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	return m.GetWriterFromPath(url.Path)
+}
+
+// GetWriterFromPath returns the writer from file path, the file is truncated
+func (m *MemFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	m.WriteFile(filePath, nil)
+	return &memWriter{fs: m, name: cleanPath(filePath)}, nil
+}
+
+// Stat returns the file info
+func (m *MemFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name := cleanPath(filePath)
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: filePath, Err: fs.ErrNotExist}
+	}
+	return memFileInfo{name: path.Base(name), size: int64(len(f.data)), modTime: f.modTime}, nil
+}
+
+// GetPath returns the file path
+func (m *MemFileSystem) GetPath(file *os.File, url *url.URL, basePath string, fileName string) string {
+	if url != nil {
+		return url.Path
+	}
+	if file != nil {
+		return file.Name()
+	}
+	return path.Join(basePath, fileName)
+}
+
+// GetBasePath returns the base file path
+func (m *MemFileSystem) GetBasePath(filePath string) string {
+	return path.Dir(cleanPath(filePath))
+}
+
+// GetFileName returns the file name
+func (m *MemFileSystem) GetFileName(filePath string) string {
+	base := path.Base(filepath.ToSlash(filePath))
+	return base[:len(base)-len(path.Ext(base))]
+}
+
+// LocateFromURL returns the file URL
+func (m *MemFileSystem) LocateFromURL(basePath string, fileName string) *url.URL {
+	return &url.URL{Scheme: "mem", Path: cleanPath(path.Join(basePath, fileName))}
+}
+
+// GetURL return the file URL
+func (m *MemFileSystem) GetURL(basePath string, fileName string) *url.URL {
+	return m.LocateFromURL(basePath, fileName)
+}
+
+// memWriter appends to an in-memory file, every write is visible immediately
+type memWriter struct {
+	fs   *MemFileSystem
+	name string
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	w.fs.mu.Lock()
+	defer w.fs.mu.Unlock()
+	f, ok := w.fs.files[w.name]
+	if !ok {
+		f = &memFile{}
+		w.fs.files[w.name] = f
+	}
+	f.data = append(f.data, p...)
+	f.modTime = time.Now()
+	return len(p), nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
+
+// cleanPath returns the slash separated clean path without leading `/` or `./`
+func cleanPath(filePath string) string {
+	p := path.Clean("/" + filepath.ToSlash(filePath))
+	if p == "/" {
+		return "."
+	}
+	return p[1:]
+}
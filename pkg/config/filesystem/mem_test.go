@@ -0,0 +1,111 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS()
+	m.WriteFile("conf/app.toml", []byte("a = 1"))
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "conf/app.toml", want: "a = 1"},
+		{path: "/conf/app.toml", want: "a = 1"},
+		{path: "./conf/../conf/app.toml", want: "a = 1"},
+		{path: "conf/missing.toml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r, err := m.GetReader(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetReader(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("GetReader(%q) error = %v, want fs.ErrNotExist", tt.path, err)
+				}
+				return
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("GetReader(%q) = %q, want %q", tt.path, b, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemFSWriter(t *testing.T) {
+	m := NewMemFS()
+	m.WriteFile("app.toml", []byte("old content"))
+	w, err := m.GetWriterFromPath("app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "a = "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "2"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := m.ReadFile("app.toml")
+	if err != nil || string(b) != "a = 2" {
+		t.Errorf("ReadFile() = %q, %v, want truncated and rewritten file", b, err)
+	}
+	info, err := m.Stat("app.toml")
+	if err != nil || info.Size() != 5 || info.Name() != "app.toml" {
+		t.Errorf("Stat() = %v, %v, want app.toml of 5 bytes", info, err)
+	}
+	m.Remove("app.toml")
+	if _, err := m.Stat("app.toml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat() after Remove error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestIOFS(t *testing.T) {
+	f := FromFS(fstest.MapFS{"conf/app.toml": {Data: []byte("a = 1")}})
+	r, err := f.GetReader("/conf/app.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil || string(b) != "a = 1" {
+		t.Errorf("GetReader() = %q, %v, want a = 1", b, err)
+	}
+	if _, err := f.Stat("conf/app.toml"); err != nil {
+		t.Errorf("Stat() error = %v", err)
+	}
+	if _, err := f.GetWriterFromPath("conf/app.toml"); err != ErrReadOnly {
+		t.Errorf("GetWriterFromPath() error = %v, want ErrReadOnly", err)
+	}
+	if got := f.GetFileName("conf/app.toml"); got != "app" {
+		t.Errorf("GetFileName() = %q, want app", got)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: "."},
+		{path: "/", want: "."},
+		{path: "a/b", want: "a/b"},
+		{path: "/a/./b/", want: "a/b"},
+		{path: "../a", want: "a"},
+	}
+	for _, tt := range tests {
+		if got := cleanPath(tt.path); got != tt.want {
+			t.Errorf("cleanPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
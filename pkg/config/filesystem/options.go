@@ -7,6 +7,7 @@ type options struct {
 	proxyPort           int
 	maxHostConnections  int
 	maxTotalConnections int
+	root                string
 }
 
 // Option option interface for config file system
@@ -44,6 +45,11 @@ func WithMaxTotalConn(maxTotalConn int) Option {
 	return maxTotalConnOption(maxTotalConn)
 }
 
+// WithRoot with root directory option, file paths are resolved relative to the root
+func WithRoot(root string) Option {
+	return rootOption(root)
+}
+
 type currentUserOption string
 
 func (o currentUserOption) apply(opts *options) {
@@ -79,3 +85,9 @@ type maxTotalConnOption int
 func (o maxTotalConnOption) apply(opts *options) {
 	opts.maxTotalConnections = int(o)
 }
+
+type rootOption string
+
+func (o rootOption) apply(opts *options) {
+	opts.root = string(o)
+}
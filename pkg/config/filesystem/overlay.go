@@ -0,0 +1,106 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+)
+
+var _ FileSystem = (*OverlayFileSystem)(nil)
+var _ PathWriter = (*OverlayFileSystem)(nil)
+var _ Stater = (*OverlayFileSystem)(nil)
+
+// OverlayFileSystem combines a read-only lower layer with a writable upper layer,
+// reads prefer the upper layer and writes always go to the upper layer.
+//
+// A config installed read-only at `/usr/share/app/config.toml` can be customized and saved to
+// `/var/lib/app/config.toml` with the lower and upper layers rooted by WithRoot:
+//
+//	NewOverlay(NewFileSystem(WithRoot("/usr/share/app")), NewFileSystem(WithRoot("/var/lib/app")))
+type OverlayFileSystem struct {
+	lower FileSystem
+	upper FileSystem
+}
+
+// NewOverlay new overlay file system
+func NewOverlay(lower, upper FileSystem) *OverlayFileSystem {
+	return &OverlayFileSystem{lower: lower, upper: upper}
+}
+
+// GetReader returns the reader of the upper layer, or of the lower layer if the file does not exist in the upper one
+func (o *OverlayFileSystem) GetReader(filePath string) (io.Reader, error) {
+	reader, err := o.upper.GetReader(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.GetReader(filePath)
+	}
+	return reader, err
+}
+
+// GetReaderFromURL returns the reader from URL of the upper layer, or of the lower layer if the file does not exist in the upper one
+func (o *OverlayFileSystem) GetReaderFromURL(url *url.URL) (io.Reader, error) {
+	reader, err := o.upper.GetReaderFromURL(url)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.lower.GetReaderFromURL(url)
+	}
+	return reader, err
+}
+
+// GetWriter returns the writer of the upper layer
+func (o *OverlayFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return o.upper.GetWriter(file)
+}
+
+// GetWriterFromURL returns the writer from URL of the upper layer
+func (o *OverlayFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	return o.upper.GetWriterFromURL(url)
+}
+
+// GetWriterFromPath returns the writer from file path of the upper layer, the file URL
+// is passed to the upper layer if it does not open writers by file path
+func (o *OverlayFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	if pathWriter, ok := o.upper.(PathWriter); ok {
+		return pathWriter.GetWriterFromPath(filePath)
+	}
+	return o.upper.GetWriterFromURL(&url.URL{Scheme: "file", Path: filePath})
+}
+
+// Stat returns the file info of the upper layer, or of the lower layer if the file does not exist in the upper one
+func (o *OverlayFileSystem) Stat(filePath string) (fs.FileInfo, error) {
+	if stater, ok := o.upper.(Stater); ok {
+		info, err := stater.Stat(filePath)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return info, err
+		}
+	}
+	if stater, ok := o.lower.(Stater); ok {
+		return stater.Stat(filePath)
+	}
+	return nil, &fs.PathError{Op: "stat", Path: filePath, Err: errors.New("file system does not support stat")}
+}
+
+// GetPath returns the file path
+func (o *OverlayFileSystem) GetPath(file *os.File, url *url.URL, basePath string, fileName string) string {
+	return o.upper.GetPath(file, url, basePath, fileName)
+}
+
+// GetBasePath returns the base file path
+func (o *OverlayFileSystem) GetBasePath(filePath string) string {
+	return o.upper.GetBasePath(filePath)
+}
+
+// GetFileName returns the file name
+func (o *OverlayFileSystem) GetFileName(filePath string) string {
+	return o.upper.GetFileName(filePath)
+}
+
+// LocateFromURL returns the file URL
+func (o *OverlayFileSystem) LocateFromURL(basePath string, fileName string) *url.URL {
+	return o.upper.LocateFromURL(basePath, fileName)
+}
+
+// GetURL return the file URL
+func (o *OverlayFileSystem) GetURL(basePath string, fileName string) *url.URL {
+	return o.upper.GetURL(basePath, fileName)
+}
@@ -0,0 +1,142 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readAll(t *testing.T, r io.Reader, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestOverlay(t *testing.T) {
+	lower, upper := NewMemFS(), NewMemFS()
+	lower.WriteFile("app.toml", []byte("lower"))
+	lower.WriteFile("lower.toml", []byte("lower only"))
+	upper.WriteFile("app.toml", []byte("upper"))
+	o := NewOverlay(lower, upper)
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "app.toml", want: "upper"},
+		{path: "lower.toml", want: "lower only"},
+		{path: "missing.toml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r, err := o.GetReader(tt.path)
+			if tt.wantErr {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("GetReader(%q) error = %v, want fs.ErrNotExist", tt.path, err)
+				}
+				return
+			}
+			if got := readAll(t, r, err); got != tt.want {
+				t.Errorf("GetReader(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+			if _, err := o.Stat(tt.path); err != nil {
+				t.Errorf("Stat(%q) error = %v", tt.path, err)
+			}
+		})
+	}
+	w, err := o.GetWriterFromPath("lower.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "saved"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := upper.ReadFile("lower.toml"); string(b) != "saved" {
+		t.Errorf("upper file = %q, want saved", b)
+	}
+	if b, _ := lower.ReadFile("lower.toml"); string(b) != "lower only" {
+		t.Errorf("lower file = %q, want it unchanged", b)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "app.toml"), []byte("rooted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := NewFileSystem(WithRoot(root))
+	r, err := f.GetReader("app.toml")
+	if got := readAll(t, r, err); got != "rooted" {
+		t.Errorf("GetReader() = %q, want rooted", got)
+	}
+	w, err := f.GetWriterFromPath("conf/new.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "conf", "new.toml")); err != nil || string(b) != "new" {
+		t.Errorf("written file = %q, %v, want it created under the root", b, err)
+	}
+}
+
+// urlWriterFS hides the PathWriter of the file system, writers are only opened by URL
+type urlWriterFS struct {
+	FileSystem
+}
+
+func TestOverlayWriterWithoutPathWriter(t *testing.T) {
+	dir := t.TempDir()
+	local := filepath.Join(dir, "app.toml")
+	if err := os.WriteFile(local, []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lower, upper := NewMemFS(), NewMemFS()
+	lower.WriteFile(local, []byte("lower"))
+	tests := []struct {
+		name    string
+		upper   FileSystem
+		wantErr bool
+	}{
+		{name: "upper writes by URL", upper: urlWriterFS{upper}},
+		{name: "read only upper", upper: urlWriterFS{FromFS(os.DirFS(dir))}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewOverlay(lower, tt.upper).GetWriterFromPath(local)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWriterFromPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if _, err := io.WriteString(w, "saved"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if b, err := os.ReadFile(local); err != nil || string(b) != "local" {
+				t.Errorf("local file = %q, %v, want it untouched", b, err)
+			}
+			if b, _ := lower.ReadFile(local); string(b) != "lower" {
+				t.Errorf("lower file = %q, want it unchanged", b)
+			}
+		})
+	}
+	if b, _ := upper.ReadFile(local); string(b) != "saved" {
+		t.Errorf("upper file = %q, want saved", b)
+	}
+}
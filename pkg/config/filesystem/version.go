@@ -0,0 +1,160 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version is a saved revision of a configuration file
+type Version struct {
+	// Number version number, increasing with every save
+	Number int `json:"number"`
+	// Hash sha256 hash of the content
+	Hash string `json:"hash"`
+	// Time save time
+	Time time.Time `json:"time"`
+	// User user who saved the version, see WithCurrentUser
+	User string `json:"user,omitempty"`
+}
+
+// Versioner is implemented by file systems which keep the history of saved files
+type Versioner interface {
+	// History get the saved versions of the file, oldest first
+	History(filePath string) ([]Version, error)
+	// GetVersion get the content of the file at the version number
+	GetVersion(filePath string, number int) ([]byte, error)
+}
+
+var _ Versioner = (*ConfigFileSystem)(nil)
+
+// versionsMu serializes history updates
+var versionsMu sync.Mutex
+
+// History returns the saved versions of the file, versioning is enabled by WithVersion
+func (fs *ConfigFileSystem) History(filePath string) ([]Version, error) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	return readHistory(historyDir(fs.resolve(filePath)))
+}
+
+// GetVersion returns the content of the file at the version number
+func (fs *ConfigFileSystem) GetVersion(filePath string, number int) ([]byte, error) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	dir := historyDir(fs.resolve(filePath))
+	versions, err := readHistory(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Number == number {
+			return os.ReadFile(filepath.Join(dir, strconv.Itoa(number)))
+		}
+	}
+	return nil, errors.New("version " + strconv.Itoa(number) + " not found")
+}
+
+// recordInitial records the current content of the file if it has no history yet,
+// so the state before the first versioned save can be rolled back to
+func (fs *ConfigFileSystem) recordInitial(filePath string) error {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions, err := readHistory(historyDir(filePath))
+	if err != nil || len(versions) > 0 {
+		return err
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fs.recordLocked(filePath, versions, data)
+}
+
+// record stores the content as a new version and prunes versions exceeding the versioning limit
+func (fs *ConfigFileSystem) record(filePath string, data []byte) error {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions, err := readHistory(historyDir(filePath))
+	if err != nil {
+		return err
+	}
+	return fs.recordLocked(filePath, versions, data)
+}
+
+func (fs *ConfigFileSystem) recordLocked(filePath string, versions []Version, data []byte) error {
+	dir := historyDir(filePath)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if len(versions) > 0 && versions[len(versions)-1].Hash == hash {
+		return nil
+	}
+	number := 1
+	if len(versions) > 0 {
+		number = versions[len(versions)-1].Number + 1
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(number)), data, 0644); err != nil {
+		return err
+	}
+	versions = append(versions, Version{Number: number, Hash: hash, Time: time.Now(), User: fs.opts.currentUser})
+	for len(versions) > fs.opts.versioning {
+		_ = os.Remove(filepath.Join(dir, strconv.Itoa(versions[0].Number)))
+		versions = versions[1:]
+	}
+	b, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "index.json"), b, 0644)
+}
+
+// historyDir returns the directory keeping the versions of the file, `.<name>.history` next to it
+func historyDir(filePath string) string {
+	return filepath.Join(filepath.Dir(filePath), "."+filepath.Base(filePath)+".history")
+}
+
+func readHistory(dir string) ([]Version, error) {
+	b, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	if err := json.Unmarshal(b, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// versionedFile records a new version of the file when it is closed
+type versionedFile struct {
+	*os.File
+	fs   *ConfigFileSystem
+	path string
+}
+
+// Close closes the file and records its content as a new version
+func (f *versionedFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	return f.fs.record(f.path, data)
+}
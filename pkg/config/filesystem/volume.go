@@ -0,0 +1,154 @@
+package filesystem
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ FileSystem = (*VolumeFileSystem)(nil)
+var _ Revisioner = (*VolumeFileSystem)(nil)
+var _ LoadedRevisioner = (*VolumeFileSystem)(nil)
+
+// volumeDataLink the symlink which Kubernetes swaps atomically when a mounted volume is updated
+const volumeDataLink = "..data"
+
+// VolumeFileSystem read-only file system of a mounted Kubernetes ConfigMap or Secret volume,
+// every file of the directory is a key. The revision is the target of the `..data` symlink,
+// so reloading strategies fire when Kubernetes flips it.
+type VolumeFileSystem struct {
+	*ConfigFileSystem
+	dir    string
+	mu     sync.Mutex
+	loaded string
+}
+
+// VolumeSnapshot the key files of a volume read from one `..data` target
+type VolumeSnapshot struct {
+	// Revision the `..data` target the files have been read from
+	Revision string
+	// Names the sorted key file names
+	Names []string
+	// Files the content of the key files by name
+	Files map[string][]byte
+}
+
+// NewVolumeFS new volume file system of the mounted directory
+func NewVolumeFS(dir string) *VolumeFileSystem {
+	return &VolumeFileSystem{ConfigFileSystem: NewFileSystem(WithRoot(dir)), dir: dir}
+}
+
+// Files returns the key file names of the volume, hidden `..` entries are skipped
+func (v *VolumeFileSystem) Files() ([]string, error) {
+	return listKeyFiles(v.dir)
+}
+
+// Snapshot reads every key file of the volume. The `..data` symlink is resolved once and all
+// files are read from its target, so a swap during the read can not mix two versions, and
+// the snapshot revision is recorded as the loaded revision.
+func (v *VolumeFileSystem) Snapshot() (*VolumeSnapshot, error) {
+	snapshot := &VolumeSnapshot{Files: make(map[string][]byte)}
+	dir := v.dir
+	if target, err := os.Readlink(filepath.Join(v.dir, volumeDataLink)); err == nil {
+		snapshot.Revision = target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(v.dir, target)
+		}
+		dir = target
+	} else if snapshot.Revision, err = v.modifiedRevision(); err != nil {
+		return nil, err
+	}
+	names, err := listKeyFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Files[name] = b
+	}
+	snapshot.Names = names
+	v.mu.Lock()
+	v.loaded = snapshot.Revision
+	v.mu.Unlock()
+	return snapshot, nil
+}
+
+// LoadedRevision returns the revision of the last snapshot
+func (v *VolumeFileSystem) LoadedRevision(filePath string) (string, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.loaded, v.loaded != ""
+}
+
+// listKeyFiles returns the sorted regular file names of the directory, hidden `..` entries are skipped
+func listKeyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "..") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Revision returns the target of the `..data` symlink, or the latest modification
+// time of the files if the directory is not laid out by Kubernetes
+func (v *VolumeFileSystem) Revision(filePath string) (string, error) {
+	if target, err := os.Readlink(filepath.Join(v.dir, volumeDataLink)); err == nil {
+		return target, nil
+	}
+	return v.modifiedRevision()
+}
+
+// modifiedRevision returns the latest modification time of the key files
+func (v *VolumeFileSystem) modifiedRevision() (string, error) {
+	names, err := v.Files()
+	if err != nil {
+		return "", err
+	}
+	var latest int64
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(v.dir, name))
+		if err != nil {
+			return "", err
+		}
+		if modified := info.ModTime().UnixNano(); modified > latest {
+			latest = modified
+		}
+	}
+	return strconv.FormatInt(latest, 10), nil
+}
+
+// GetWriter always returns ErrReadOnly
+func (v *VolumeFileSystem) GetWriter(file *os.File) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromURL always returns ErrReadOnly
+func (v *VolumeFileSystem) GetWriterFromURL(url *url.URL) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+// GetWriterFromPath always returns ErrReadOnly
+func (v *VolumeFileSystem) GetWriterFromPath(filePath string) (io.Writer, error) {
+	return nil, ErrReadOnly
+}
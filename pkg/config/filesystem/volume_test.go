@@ -0,0 +1,119 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeVolume writes the files into a new timestamped directory of the volume and swaps
+// `..data` to it atomically, the way the kubelet updates mounted ConfigMaps
+func writeVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	data := "..2024_" + version
+	if err := os.Mkdir(filepath.Join(dir, data), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, data, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join(volumeDataLink, name), link); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(data, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, volumeDataLink)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVolumeSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "1", map[string]string{"db__host": "localhost", "port": "5432"})
+	v := NewVolumeFS(dir)
+	if _, ok := v.LoadedRevision(dir); ok {
+		t.Error("LoadedRevision() before a snapshot reports a revision")
+	}
+	snapshot, err := v.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Revision != "..2024_1" || !reflect.DeepEqual(snapshot.Names, []string{"db__host", "port"}) {
+		t.Errorf("Snapshot() = %s %v, want ..2024_1 [db__host port]", snapshot.Revision, snapshot.Names)
+	}
+	if got := string(snapshot.Files["port"]); got != "5432" {
+		t.Errorf("Files[port] = %q, want 5432", got)
+	}
+
+	writeVolume(t, dir, "2", map[string]string{"db__host": "db", "port": "6543"})
+	if revision, _ := v.Revision(dir); revision != "..2024_2" {
+		t.Errorf("Revision() = %q, want ..2024_2", revision)
+	}
+	if loaded, ok := v.LoadedRevision(dir); !ok || loaded != "..2024_1" {
+		t.Errorf("LoadedRevision() = %q, want the revision of the snapshot", loaded)
+	}
+	snapshot, err = v.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Revision != "..2024_2" || string(snapshot.Files["db__host"]) != "db" {
+		t.Errorf("Snapshot() after the swap = %s %q, want ..2024_2 db", snapshot.Revision, snapshot.Files["db__host"])
+	}
+}
+
+func TestVolumeSnapshotReadsOneTarget(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "1", map[string]string{"a": "1", "b": "1"})
+	// a half applied update: the top level links already resolve to a newer version
+	if err := os.Mkdir(filepath.Join(dir, "..2024_2"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "..2024_2", "b"), []byte("2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..2024_2", "b"), filepath.Join(dir, "b")); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := NewVolumeFS(dir).Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(snapshot.Files["a"]) + string(snapshot.Files["b"]); got != "11" {
+		t.Errorf("Snapshot() files = %q, want both read from the ..data target", got)
+	}
+}
+
+func TestVolumeWithoutDataLink(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "name"), []byte("app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "..hidden"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v := NewVolumeFS(dir)
+	snapshot, err := v.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snapshot.Names, []string{"name"}) || snapshot.Revision == "" {
+		t.Errorf("Snapshot() = %q %v, want [name] with a modification time revision", snapshot.Revision, snapshot.Names)
+	}
+	if revision, _ := v.Revision(dir); revision != snapshot.Revision {
+		t.Errorf("Revision() = %q, want %q", revision, snapshot.Revision)
+	}
+	if _, err := v.GetWriterFromPath("name"); err != ErrReadOnly {
+		t.Errorf("GetWriterFromPath() error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// ChangeType type of a configuration change
+type ChangeType uint8
+
+const (
+	// ChangeAdded the key has been added
+	ChangeAdded ChangeType = iota + 1
+	// ChangeRemoved the key has been removed
+	ChangeRemoved
+	// ChangeModified the value of the key has been modified
+	ChangeModified
+)
+
+// String returns the string representation of the change type
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a changed key between two configuration versions
+type Change struct {
+	Type ChangeType
+	Key  string
+	Old  any
+	New  any
+}
+
+// Versioned is implemented by configurations whose file system keeps the history of saved versions
+type Versioned interface {
+	// History returns the saved versions, oldest first
+	History() ([]filesystem.Version, error)
+	// GetVersion returns the read only configuration at the version number
+	GetVersion(number int) (configer.Configurable, error)
+	// Diff returns the changed keys from version a to version b
+	Diff(a, b int) ([]Change, error)
+	// Rollback restores and saves the configuration at the version number
+	Rollback(number int) error
+}
+
+var _ Versioned = (*config)(nil)
+
+func (c *config) History() ([]filesystem.Version, error) {
+	versioner, err := c.versioner()
+	if err != nil {
+		return nil, err
+	}
+	return versioner.History(c.GetFilePath())
+}
+
+func (c *config) GetVersion(number int) (configer.Configurable, error) {
+	configMap, err := c.versionMap(number)
+	if err != nil {
+		return nil, err
+	}
+	return c.view(configMap, c.defaults, nil), nil
+}
+
+func (c *config) Diff(a, b int) ([]Change, error) {
+	from, err := c.versionMap(a)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.versionMap(b)
+	if err != nil {
+		return nil, err
+	}
+	return diffMaps(from, to), nil
+}
+
+func (c *config) Rollback(number int) error {
+	configMap, err := c.versionMap(number)
+	if err != nil {
+		return err
+	}
+	return c.Update(func(tx *Tx) error {
+		tx.configMap = configMap
+		tx.dirty = true
+		return nil
+	})
+}
+
+func (c *config) versioner() (filesystem.Versioner, error) {
+	versioner, ok := c.fileSystem.(filesystem.Versioner)
+	if !ok {
+		return nil, errors.New("config file system doesn't keep versions")
+	}
+	return versioner, nil
+}
+
+// versionMap decodes the configuration at the version number
+func (c *config) versionMap(number int) (map[string]configer.Field, error) {
+	versioner, err := c.versioner()
+	if err != nil {
+		return nil, err
+	}
+	b, err := versioner.GetVersion(c.GetFilePath(), number)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetDecoder().Decode(b)
+}
+
+// diffMaps returns the changed leaf keys from one config map to another in key order
+func diffMaps(from, to map[string]configer.Field) []Change {
+	old := make(map[string]configer.Field)
+	_ = walkRecursive(from, nil, func(path string, f configer.Field) error {
+		if f.Type != configer.FieldTypeSection {
+			old[path] = f
+		}
+		return nil
+	})
+	var changes []Change
+	_ = walkRecursive(to, nil, func(path string, f configer.Field) error {
+		if f.Type == configer.FieldTypeSection {
+			return nil
+		}
+		o, ok := old[path]
+		delete(old, path)
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: ChangeAdded, Key: path, New: f.Value})
+		case !reflect.DeepEqual(o, f):
+			changes = append(changes, Change{Type: ChangeModified, Key: path, Old: o.Value, New: f.Value})
+		}
+		return nil
+	})
+	for path, o := range old {
+		changes = append(changes, Change{Type: ChangeRemoved, Key: path, Old: o.Value})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
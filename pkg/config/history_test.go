@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+)
+
+func TestHistory(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "[server]\nhost = \"localhost\"\nport = 8080\n",
+		WithFileSystem(filesystem.NewFileSystem(filesystem.WithVersion(3), filesystem.WithCurrentUser("alice"))))
+	if err := c.Set("server.port", int64(9090)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(func(tx *Tx) error {
+		if err := tx.Delete("server.host"); err != nil {
+			return err
+		}
+		return tx.Set("server.debug", true)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	versions, err := c.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("History() = %v, want the initial and two saved versions", versions)
+	}
+	for i, v := range versions {
+		if v.Number != i+1 || v.User != "alice" || v.Hash == "" {
+			t.Errorf("History()[%d] = %+v, want version %d saved by alice", i, v, i+1)
+		}
+	}
+	tests := []struct {
+		name string
+		a, b int
+		want []Change
+	}{
+		{name: "same version", a: 2, b: 2},
+		{name: "modified", a: 1, b: 2, want: []Change{
+			{Type: ChangeModified, Key: "server.port", Old: int64(8080), New: int64(9090)},
+		}},
+		{name: "added and removed", a: 2, b: 3, want: []Change{
+			{Type: ChangeAdded, Key: "server.debug", New: true},
+			{Type: ChangeRemoved, Key: "server.host", Old: "localhost"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff(%d, %d) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+	first, err := c.GetVersion(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := first.GetString("server.host"); err != nil || got != "localhost" {
+		t.Errorf("GetVersion(1).GetString() = %q, %v, want localhost", got, err)
+	}
+	if err := first.Set("server.host", "example.com"); err == nil {
+		t.Error("GetVersion(1).Set() succeeded on a read only version")
+	}
+	if err := c.Rollback(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.GetInt64("server.port"); err != nil || got != 8080 {
+		t.Errorf("GetInt64() after Rollback = %v, %v, want 8080", got, err)
+	}
+	versions, err = c.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Number != 2 {
+		t.Errorf("History() after Rollback = %v, want versions 2 to 4", versions)
+	}
+	if _, err := c.GetVersion(1); err == nil {
+		t.Error("GetVersion() of a pruned version succeeded")
+	}
+}
+
+func TestHistoryNotSupported(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "name = \"app\"\n", WithFileSystem(filesystem.FromFS(os.DirFS("/"))))
+	if _, err := c.History(); err == nil {
+		t.Error("History() of a file system without versions succeeded")
+	}
+}
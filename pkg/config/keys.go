@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// WalkFunc is called for every section and value while walking the configuration,
+// path is the canonical key path of the field
+type WalkFunc func(path string, f configer.Field) error
+
+// KeyManager is implemented by configurations which support removing and enumerating keys
+type KeyManager interface {
+	// Delete removes the key
+	Delete(key string) error
+	// Rename moves the value of oldKey to newKey
+	Rename(oldKey, newKey string) error
+	// Keys returns the flattened dotted keys under the given prefix
+	Keys(prefix string) []string
+	// AllKeys returns all flattened dotted keys
+	AllKeys() []string
+	// Walk walks the configuration tree in key order
+	Walk(fn WalkFunc) error
+	// AllSettings returns the whole configuration as plain values
+	AllSettings() map[string]any
+}
+
+var _ KeyManager = (*config)(nil)
+
+func (c *config) Delete(key string) error {
+	return c.Update(func(tx *Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+func (c *config) Rename(oldKey, newKey string) error {
+	return c.Update(func(tx *Tx) error {
+		path, err := ParsePath(oldKey)
+		if err != nil {
+			return err
+		}
+		newPath, err := ParsePath(newKey)
+		if err != nil {
+			return err
+		}
+		oldPath := tx.resolve(tx.configMap, path)
+		f, ok := getPath(tx.configMap, oldPath)
+		if !ok {
+			return errors.New("config not found for key:`" + oldKey + "`")
+		}
+		if err := tx.DeletePath(path); err != nil {
+			return err
+		}
+		// encrypted values bound to their key path are encrypted again for the new key path
+		if f, err = rebind(f, oldPath, tx.resolve(tx.configMap, newPath), tx.decrypter); err != nil {
+			return err
+		}
+		return tx.SetPath(newPath, f)
+	})
+}
+
+func (c *config) Keys(prefix string) []string {
+	if prefix != "" {
+		path, err := ParsePath(prefix)
+		if err != nil {
+			return nil
+		}
+		prefix = path.String()
+	}
+	var keys []string
+	for _, key := range c.AllKeys() {
+		if prefix == "" || key == prefix || strings.HasPrefix(key, prefix+".") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c *config) AllKeys() []string {
+	var keys []string
+	_ = c.Walk(func(path string, f configer.Field) error {
+		if f.Type != configer.FieldTypeSection {
+			keys = append(keys, path)
+		}
+		return nil
+	})
+	return keys
+}
+
+func (c *config) Walk(fn WalkFunc) error {
+	c.RLock()
+	settings := c.settings()
+	c.RUnlock()
+	return walkRecursive(settings, nil, fn)
+}
+
+func (c *config) AllSettings() map[string]any {
+	c.RLock()
+	defer c.RUnlock()
+	return field.ToMap(c.settings())
+}
+
+// settings returns the effective configuration with defaults and runtime overrides applied, the caller must hold the lock
+func (c *config) settings() map[string]configer.Field {
+	settings := field.CopyMap(c.defaults)
+	field.MergeMap(settings, c.configMap)
+	field.MergeMap(settings, c.overrides)
+	return settings
+}
+
+func walkRecursive(configMap map[string]configer.Field, prefix Path, fn WalkFunc) error {
+	keys := make([]string, 0, len(configMap))
+	for key := range configMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := prefix.Child(key)
+		f := configMap[key]
+		if err := fn(path.String(), f); err != nil {
+			return err
+		}
+		if f.Type != configer.FieldTypeSection {
+			continue
+		}
+		if err := walkRecursive(f.Value.(map[string]configer.Field), path, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func keyManager() (KeyManager, error) {
+	m, ok := L().(KeyManager)
+	if !ok {
+		return nil, errors.New("default config does not support key management")
+	}
+	return m, nil
+}
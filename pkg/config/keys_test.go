@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+const keysDocument = `
+name = "app"
+
+[server]
+host = "localhost"
+port = 8080
+
+[server.tls]
+enabled = true
+`
+
+func TestKeys(t *testing.T) {
+	c := newTestConfig(t, "config.toml", keysDocument)
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "", want: []string{"name", "server.host", "server.port", "server.tls.enabled"}},
+		{prefix: "server", want: []string{"server.host", "server.port", "server.tls.enabled"}},
+		{prefix: "server.tls", want: []string{"server.tls.enabled"}},
+		{prefix: "serv", want: nil},
+		{prefix: "missing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := c.Keys(tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keys(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+	if got, want := c.AllKeys(), c.Keys(""); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteAndRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(c *config) error
+		wantErr  bool
+		wantKeys []string
+	}{
+		{
+			name:     "delete value",
+			fn:       func(c *config) error { return c.Delete("server.port") },
+			wantKeys: []string{"name", "server.host", "server.tls.enabled"},
+		},
+		{
+			name:     "delete section",
+			fn:       func(c *config) error { return c.Delete("server") },
+			wantKeys: []string{"name"},
+		},
+		{
+			name:     "delete missing",
+			fn:       func(c *config) error { return c.Delete("server.missing") },
+			wantErr:  true,
+			wantKeys: []string{"name", "server.host", "server.port", "server.tls.enabled"},
+		},
+		{
+			name:     "rename value",
+			fn:       func(c *config) error { return c.Rename("server.port", "server.listen.port") },
+			wantKeys: []string{"name", "server.host", "server.listen.port", "server.tls.enabled"},
+		},
+		{
+			name:     "rename missing",
+			fn:       func(c *config) error { return c.Rename("server.missing", "server.other") },
+			wantErr:  true,
+			wantKeys: []string{"name", "server.host", "server.port", "server.tls.enabled"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.toml", keysDocument)
+			if err := tt.fn(c); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := c.AllKeys(); !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("AllKeys() = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	c := newTestConfig(t, "config.toml", keysDocument)
+	var paths []string
+	if err := c.Walk(func(path string, f configer.Field) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"name", "server", "server.host", "server.port", "server.tls", "server.tls.enabled"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() paths = %v, want %v", paths, want)
+	}
+	stop := errors.New("stop")
+	if err := c.Walk(func(path string, f configer.Field) error { return stop }); err != stop {
+		t.Errorf("Walk() error = %v, want %v", err, stop)
+	}
+}
+
+func TestAllSettings(t *testing.T) {
+	c := newTestConfig(t, "config.toml", keysDocument)
+	if err := c.SetInMemory("server.host", "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name": "app",
+		"server": map[string]any{
+			"host": "example.com",
+			"port": int64(8080),
+			"tls":  map[string]any{"enabled": true},
+		},
+	}
+	if got := c.AllSettings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllSettings() = %#v, want %#v", got, want)
+	}
+}
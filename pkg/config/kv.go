@@ -0,0 +1,185 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/kvstore"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// NewKVConfig creates a configuration from the keys under prefix of the key-value store,
+// `prefix/db/host` becomes `db.host`. Values are parsed into typed fields, updates are put
+// back to the store and the configuration follows the store until ctx is done. If following
+// the store fails for good the error is passed to the WithErrorHandler handler.
+func NewKVConfig(ctx context.Context, provider kvstore.Provider, prefix string, opts ...Option) (configer.Configurable, error) {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	c := &config{
+		fileSystem:    filesystem.DefaultFileSystem,
+		configMap:     make(map[string]configer.Field),
+		overrides:     make(map[string]configer.Field),
+		validators:    options.validators,
+		normalizeKeys: options.normalizeKeys,
+		decrypter:     options.decrypter,
+		secrets:       newSecretResolver(options),
+		encoder:       encoding.DefaultCodec,
+		decoder:       encoding.DefaultCodec,
+	}
+	defaults, err := loadDefaults(options.defaults)
+	if err != nil {
+		return nil, err
+	}
+	c.defaults = defaults
+	kvs, revision, err := provider.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		applyKV(c.configMap, kvstore.Event{Type: kvstore.EventPut, KV: kv}, prefix)
+	}
+	c.persist = func(from, to map[string]configer.Field) error {
+		return putKV(ctx, provider, prefix, from, to)
+	}
+	events, err := provider.Watch(ctx, prefix, revision)
+	if err != nil {
+		return nil, err
+	}
+	handleError := options.errorHandler
+	if handleError == nil {
+		handleError = logError
+	}
+	go func() {
+		for event := range events {
+			if event.Type == kvstore.EventError {
+				handleError(errors.New("follow key-value store prefix `" + prefix + "`: " + event.Err.Error()))
+				continue
+			}
+			c.Lock()
+			before := c.snapshot()
+			// copy on write, the previous map may still be read through a transaction or a view
+			configMap := field.CopyMap(c.configMap)
+			applyKV(configMap, event, prefix)
+			c.configMap = configMap
+			c.decrypted.reset()
+			changes := c.changes(before)
+			c.Unlock()
+			c.notify(changes)
+		}
+	}()
+	return c, nil
+}
+
+// applyKV applies the store event to the config map
+func applyKV(configMap map[string]configer.Field, event kvstore.Event, prefix string) {
+	levels := kvstore.TrimPrefix(event.KV.Key, prefix)
+	if len(levels) == 0 {
+		return
+	}
+	path := make(Path, 0, len(levels))
+	for _, level := range levels {
+		path = append(path, Segment{Key: level})
+	}
+	if event.Type == kvstore.EventDelete {
+		_, _ = deletePath(configMap, path)
+		return
+	}
+	_ = setPath(configMap, path, configer.Atof(parseValue(event.KV.Value)))
+}
+
+// putKV puts the changed leaf keys to the store and deletes the removed ones. The changes are applied
+// in one transaction if the provider is a kvstore.Transactor, otherwise one key at a time and the
+// applied changes are reverted if a later one fails.
+func putKV(ctx context.Context, provider kvstore.Provider, prefix string, from, to map[string]configer.Field) error {
+	changes := diffMaps(from, to)
+	ops := make([]kvstore.Op, 0, len(changes))
+	for _, change := range changes {
+		path, err := ParsePath(change.Key)
+		if err != nil {
+			return err
+		}
+		levels := make([]string, 0, len(path))
+		for _, s := range path {
+			if s.IsIndex {
+				return errors.New("config key:`" + change.Key + "` can not be stored in a key-value store")
+			}
+			levels = append(levels, s.Key)
+		}
+		key := strings.TrimSuffix(prefix, kvstore.Separator) + kvstore.Separator + strings.Join(levels, kvstore.Separator)
+		if change.Type == ChangeRemoved {
+			ops = append(ops, kvstore.Op{Key: key, Delete: true})
+		} else {
+			ops = append(ops, kvstore.Op{Key: key, Value: formatValue(change.New)})
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	if txn, ok := provider.(kvstore.Transactor); ok {
+		return txn.Txn(ctx, ops)
+	}
+	for i, op := range ops {
+		if err := applyOp(ctx, provider, op); err != nil {
+			// revert the applied changes in reverse order
+			for j := i - 1; j >= 0; j-- {
+				revert := kvstore.Op{Key: ops[j].Key, Delete: changes[j].Type == ChangeAdded}
+				if !revert.Delete {
+					revert.Value = formatValue(changes[j].Old)
+				}
+				if rerr := applyOp(ctx, provider, revert); rerr != nil {
+					return errors.New(err.Error() + ", revert key `" + revert.Key + "`: " + rerr.Error())
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// applyOp puts or deletes the key of the operation
+func applyOp(ctx context.Context, provider kvstore.Provider, op kvstore.Op) error {
+	if op.Delete {
+		return provider.Delete(ctx, op.Key)
+	}
+	return provider.Put(ctx, op.Key, op.Value)
+}
+
+// parseValue parses the raw value of a key-value store or volume file into an int64, float64, bool, JSON array or object, or string
+func parseValue(b []byte) any {
+	s := string(b)
+	scalar := field.ParseScalar(s)
+	if _, ok := scalar.(string); !ok {
+		return scalar
+	}
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+		var v any
+		if err := json.Unmarshal(b, &v); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// formatValue formats the value as parsed by parseValue
+func formatValue(v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return []byte(v)
+	case map[string]configer.Field:
+		b, _ := json.Marshal(field.ToMap(v))
+		return b
+	case []any, []string, []int, []int64, []float64, []bool, map[string]any:
+		b, _ := json.Marshal(v)
+		return b
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,303 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/kvstore"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// fakeProvider in-memory key-value store, watch events are sent by the test
+type fakeProvider struct {
+	mu       sync.Mutex
+	kvs      map[string][]byte
+	revision int64
+	// watchedFrom the revision Watch has been called with
+	watchedFrom int64
+	events      chan kvstore.Event
+	// failPut fails puts of the key
+	failPut string
+}
+
+func newFakeProvider(kvs map[string]string) *fakeProvider {
+	p := &fakeProvider{kvs: make(map[string][]byte), revision: 7, events: make(chan kvstore.Event)}
+	for key, value := range kvs {
+		p.kvs[key] = []byte(value)
+	}
+	return p
+}
+
+func (p *fakeProvider) Get(ctx context.Context, key string) (*kvstore.KV, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	value, ok := p.kvs[key]
+	if !ok {
+		return nil, nil
+	}
+	return &kvstore.KV{Key: key, Value: value}, nil
+}
+
+func (p *fakeProvider) List(ctx context.Context, prefix string) ([]kvstore.KV, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var kvs []kvstore.KV
+	for key, value := range p.kvs {
+		if strings.HasPrefix(key, prefix) {
+			kvs = append(kvs, kvstore.KV{Key: key, Value: value})
+		}
+	}
+	return kvs, p.revision, nil
+}
+
+func (p *fakeProvider) Put(ctx context.Context, key string, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key == p.failPut {
+		return errors.New("put `" + key + "` failed")
+	}
+	p.kvs[key] = value
+	return nil
+}
+
+func (p *fakeProvider) Delete(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.kvs, key)
+	return nil
+}
+
+func (p *fakeProvider) Watch(ctx context.Context, prefix string, revision int64) (<-chan kvstore.Event, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.watchedFrom = revision
+	return p.events, nil
+}
+
+// txnProvider fake store which applies the operations of a transaction at once
+type txnProvider struct {
+	*fakeProvider
+	txns [][]kvstore.Op
+}
+
+func (p *txnProvider) Txn(ctx context.Context, ops []kvstore.Op) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.txns = append(p.txns, ops)
+	for _, op := range ops {
+		if op.Key == p.failPut {
+			return errors.New("txn `" + op.Key + "` failed")
+		}
+	}
+	for _, op := range ops {
+		if op.Delete {
+			delete(p.kvs, op.Key)
+		} else {
+			p.kvs[op.Key] = op.Value
+		}
+	}
+	return nil
+}
+
+// eventually retries the condition for a second
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("condition not met")
+}
+
+func TestKVConfig(t *testing.T) {
+	p := newFakeProvider(map[string]string{
+		"/app/db/host":  "localhost",
+		"/app/db/port":  "5432",
+		"/app/debug":    "true",
+		"/app/ratio":    "0.5",
+		"/app/tags":     `["a","b"]`,
+		"/other/ignore": "x",
+	})
+	errs := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, err := NewKVConfig(ctx, p, "/app/", WithErrorHandler(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*config)
+	if p.watchedFrom != 7 {
+		t.Errorf("Watch() revision = %d, want the revision of List", p.watchedFrom)
+	}
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{key: "db.host", want: "localhost"},
+		{key: "db.port", want: int64(5432)},
+		{key: "debug", want: true},
+		{key: "ratio", want: 0.5},
+	}
+	for _, tt := range tests {
+		if got, err := c.Get(tt.key); err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+	if got, err := c.Get("tags"); err != nil || !reflect.DeepEqual(got, []any{"a", "b"}) {
+		t.Errorf("Get(tags) = %v, %v, want [a b]", got, err)
+	}
+	if c.ContainsKey("ignore") {
+		t.Error("keys outside of the prefix are loaded")
+	}
+
+	p.events <- kvstore.Event{Type: kvstore.EventPut, KV: kvstore.KV{Key: "/app/db/port", Value: []byte("6543")}}
+	p.events <- kvstore.Event{Type: kvstore.EventDelete, KV: kvstore.KV{Key: "/app/debug"}}
+	eventually(t, func() bool {
+		port, _ := c.GetInt64("db.port")
+		return port == 6543 && !c.ContainsKey("debug")
+	})
+
+	p.events <- kvstore.Event{Type: kvstore.EventError, Err: errors.New("compacted")}
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "compacted") {
+			t.Errorf("handled error = %v, want the watch error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watch error not handled")
+	}
+
+	if err := c.Update(func(tx *Tx) error {
+		if err := tx.Set("db.user", "admin"); err != nil {
+			return err
+		}
+		return tx.Delete("ratio")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if string(p.kvs["/app/db/user"]) != "admin" {
+		t.Errorf("store db/user = %q, want admin", p.kvs["/app/db/user"])
+	}
+	if _, ok := p.kvs["/app/ratio"]; ok {
+		t.Error("deleted key still in the store")
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want any
+	}{
+		{raw: "42", want: int64(42)},
+		{raw: "-1", want: int64(-1)},
+		{raw: "1.5", want: 1.5},
+		{raw: "false", want: false},
+		{raw: "text", want: "text"},
+		{raw: `{"a":1}`, want: map[string]any{"a": float64(1)}},
+		{raw: "[1", want: "[1"},
+		{raw: "NaN", want: "NaN"},
+		{raw: "Inf", want: "Inf"},
+		{raw: "-infinity", want: "-infinity"},
+		{raw: "0x10", want: "0x10"},
+		{raw: "1_000", want: "1_000"},
+		{raw: "t", want: "t"},
+		{raw: "F", want: "F"},
+		{raw: "TRUE", want: "TRUE"},
+		{raw: "-2.5", want: -2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got := parseValue([]byte(tt.raw))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseValue(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+			if _, isMap := got.(map[string]any); !isMap {
+				if back := string(formatValue(got)); back != tt.raw {
+					t.Errorf("formatValue(%#v) = %q, want %q", got, back, tt.raw)
+				}
+			}
+		})
+	}
+}
+
+func TestPutKV(t *testing.T) {
+	kvs := map[string]string{"/app/a": "1", "/app/b": "2", "/app/c": "3"}
+	from := map[string]configer.Field{"a": configer.Atof(int64(1)), "b": configer.Atof(int64(2)), "c": configer.Atof(int64(3))}
+	to := map[string]configer.Field{"a": configer.Atof(int64(10)), "c": configer.Atof(int64(30)), "d": configer.Atof(int64(4))}
+	tests := []struct {
+		name     string
+		txn      bool
+		failPut  string
+		want     map[string]string
+		wantErr  bool
+		wantTxns int
+	}{
+		{name: "one key at a time", want: map[string]string{"/app/a": "10", "/app/c": "30", "/app/d": "4"}},
+		{name: "reverted", failPut: "/app/d", want: kvs, wantErr: true},
+		{name: "transaction", txn: true, want: map[string]string{"/app/a": "10", "/app/c": "30", "/app/d": "4"}, wantTxns: 1},
+		{name: "failed transaction", txn: true, failPut: "/app/d", want: kvs, wantErr: true, wantTxns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeProvider(kvs)
+			fake.failPut = tt.failPut
+			var provider kvstore.Provider = fake
+			txn := &txnProvider{fakeProvider: fake}
+			if tt.txn {
+				provider = txn
+			}
+			if err := putKV(context.Background(), provider, "/app/", from, to); (err != nil) != tt.wantErr {
+				t.Fatalf("putKV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := make(map[string]string)
+			for key, value := range fake.kvs {
+				got[key] = string(value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("store = %v, want %v", got, tt.want)
+			}
+			if len(txn.txns) != tt.wantTxns {
+				t.Errorf("transactions = %d, want %d", len(txn.txns), tt.wantTxns)
+			}
+		})
+	}
+}
+
+func TestKVConfigEventResetsDecrypted(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	encrypted, err := a.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newFakeProvider(map[string]string{"/app/password": encrypted})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, err := NewKVConfig(ctx, p, "/app/", WithDecrypter(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*config)
+	if got, err := c.GetString("password"); err != nil || got != "s3cret" {
+		t.Fatalf("GetString(password) = %q, %v, want s3cret", got, err)
+	}
+	p.events <- kvstore.Event{Type: kvstore.EventPut, KV: kvstore.KV{Key: "/app/password", Value: []byte("plain")}}
+	eventually(t, func() bool {
+		got, _ := c.GetString("password")
+		return got == "plain"
+	})
+	c.decrypted.Lock()
+	defer c.decrypted.Unlock()
+	if len(c.decrypted.fields) != 0 {
+		t.Errorf("decrypted values = %v, want them dropped after the event", c.decrypted.fields)
+	}
+}
@@ -0,0 +1,50 @@
+package kvstore
+
+import (
+	"context"
+	"time"
+)
+
+// backoff doubles the delay between retries up to its maximum
+type backoff struct {
+	min  time.Duration
+	max  time.Duration
+	next time.Duration
+}
+
+func newBackoff(opts *options) *backoff {
+	return &backoff{min: opts.minBackoff, max: opts.maxBackoff}
+}
+
+// wait waits for the next delay, false if ctx is done first
+func (b *backoff) wait(ctx context.Context) bool {
+	if b.next < b.min {
+		b.next = b.min
+	}
+	timer := time.NewTimer(b.next)
+	defer timer.Stop()
+	if b.next *= 2; b.next > b.max {
+		b.next = b.max
+	}
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reset restarts the delays at the minimum after a success
+func (b *backoff) reset() {
+	b.next = 0
+}
+
+// send sends the event, false if ctx is done first
+func send(ctx context.Context, events chan<- Event, event Event) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
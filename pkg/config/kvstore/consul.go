@@ -0,0 +1,262 @@
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Provider = (*ConsulProvider)(nil)
+var _ Transactor = (*ConsulProvider)(nil)
+
+// consulMaxTxnOps the maximum number of operations of a Consul transaction
+const consulMaxTxnOps = 64
+
+// ConsulProvider speaks the Consul KV HTTP API (`/v1/kv/` and `/v1/txn`), watching uses blocking queries
+type ConsulProvider struct {
+	endpoint string
+	client   *http.Client
+	opts     *options
+	mu       sync.Mutex
+	// listed the pairs of the last List of each prefix, Consul keeps no history of deleted keys
+	// so watches compare against them to report keys deleted since List
+	listed map[string]consulListing
+}
+
+// consulListing the pairs of a prefix at an index
+type consulListing struct {
+	index int64
+	kvs   []KV
+}
+
+// NewConsulProvider new Consul KV provider for the agent address, e.g. `http://127.0.0.1:8500`
+func NewConsulProvider(endpoint string, client *http.Client, opts ...Option) *ConsulProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ConsulProvider{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+		opts:     newOptions(opts),
+		listed:   make(map[string]consulListing),
+	}
+}
+
+type consulKV struct {
+	Key         string `json:"Key"`
+	Value       []byte `json:"Value"`
+	ModifyIndex int64  `json:"ModifyIndex"`
+}
+
+// Get returns the pair of the key
+func (p *ConsulProvider) Get(ctx context.Context, key string) (*KV, error) {
+	kvs, _, err := p.list(ctx, key, false, 0)
+	if err != nil || len(kvs) == 0 {
+		return nil, err
+	}
+	return &kvs[0], nil
+}
+
+// List returns all pairs whose keys start with prefix and the Consul index
+func (p *ConsulProvider) List(ctx context.Context, prefix string) ([]KV, int64, error) {
+	kvs, index, err := p.list(ctx, prefix, true, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	p.mu.Lock()
+	p.listed[prefix] = consulListing{index: index, kvs: kvs}
+	p.mu.Unlock()
+	return kvs, index, nil
+}
+
+// Put creates or updates the key
+func (p *ConsulProvider) Put(ctx context.Context, key string, value []byte) error {
+	return p.do(ctx, http.MethodPut, key, bytes.NewReader(value))
+}
+
+// Delete deletes the key
+func (p *ConsulProvider) Delete(ctx context.Context, key string) error {
+	return p.do(ctx, http.MethodDelete, key, nil)
+}
+
+// Txn applies the operations in a single transaction, Consul accepts at most 64 operations
+func (p *ConsulProvider) Txn(ctx context.Context, ops []Op) error {
+	if len(ops) > consulMaxTxnOps {
+		return errors.New("consul txn: " + strconv.Itoa(len(ops)) + " operations exceed the limit of " + strconv.Itoa(consulMaxTxnOps))
+	}
+	txn := make([]map[string]any, 0, len(ops))
+	for _, op := range ops {
+		kv := map[string]any{"Verb": "set", "Key": strings.TrimPrefix(op.Key, "/"), "Value": op.Value}
+		if op.Delete {
+			kv = map[string]any{"Verb": "delete", "Key": strings.TrimPrefix(op.Key, "/")}
+		}
+		txn = append(txn, map[string]any{"KV": kv})
+	}
+	body, err := json.Marshal(txn)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.endpoint+"/v1/txn", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("consul txn: " + resp.Status)
+	}
+	return nil
+}
+
+// Watch watches the keys under prefix after the index with blocking queries and reports the
+// differences between results. Keys deleted after the index are only reported if the index is
+// the one returned by the last List of the prefix.
+func (p *ConsulProvider) Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error) {
+	p.mu.Lock()
+	listing, ok := p.listed[prefix]
+	p.mu.Unlock()
+	last := make(map[string]KV)
+	if ok && listing.index == revision {
+		for _, kv := range listing.kvs {
+			last[kv.Key] = kv
+		}
+	} else {
+		// without the listing only keys modified after the index are reported
+		kvs, _, err := p.list(ctx, prefix, true, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			if kv.Revision <= revision {
+				last[kv.Key] = kv
+			}
+		}
+	}
+	index := revision
+	if index < 1 {
+		index = 1
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		b := newBackoff(p.opts)
+		for {
+			start := time.Now()
+			kvs, next, err := p.list(ctx, prefix, true, index)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if _, fatal := err.(fatalError); fatal {
+					send(ctx, events, Event{Type: EventError, Err: err})
+					return
+				}
+				if !b.wait(ctx) {
+					return
+				}
+				continue
+			}
+			current := make(map[string]KV, len(kvs))
+			for _, kv := range kvs {
+				current[kv.Key] = kv
+				if old, ok := last[kv.Key]; ok && old.Revision == kv.Revision {
+					continue
+				}
+				if !send(ctx, events, Event{Type: EventPut, KV: kv}) {
+					return
+				}
+			}
+			for key, kv := range last {
+				if _, ok := current[key]; ok {
+					continue
+				}
+				if !send(ctx, events, Event{Type: EventDelete, KV: kv}) {
+					return
+				}
+			}
+			last = current
+			if next <= index && time.Since(start) < time.Second {
+				// the query returned without blocking, the index is missing or went backwards
+				if !b.wait(ctx) {
+					return
+				}
+			} else {
+				b.reset()
+			}
+			if index = next; index < 1 {
+				index = 1
+			}
+		}
+	}()
+	return events, nil
+}
+
+// list reads the key or the keys under the prefix, index > 0 blocks until the data changes after index
+func (p *ConsulProvider) list(ctx context.Context, key string, recurse bool, index int64) ([]KV, int64, error) {
+	query := url.Values{}
+	if recurse {
+		query.Set("recurse", "true")
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatInt(index, 10))
+	}
+	u := p.endpoint + "/v1/kv/" + strings.TrimPrefix(key, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	next, _ := strconv.ParseInt(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, next, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		if permanentStatus(resp.StatusCode) {
+			return nil, 0, fatalError("consul kv: " + resp.Status)
+		}
+		return nil, 0, errors.New("consul kv: " + resp.Status)
+	}
+	var ckvs []consulKV
+	if err := json.NewDecoder(resp.Body).Decode(&ckvs); err != nil {
+		return nil, 0, err
+	}
+	kvs := make([]KV, 0, len(ckvs))
+	for _, ckv := range ckvs {
+		kvs = append(kvs, KV{Key: ckv.Key, Value: ckv.Value, Revision: ckv.ModifyIndex})
+	}
+	return kvs, next, nil
+}
+
+func (p *ConsulProvider) do(ctx context.Context, method, key string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, method, p.endpoint+"/v1/kv/"+strings.TrimPrefix(key, "/"), body)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("consul kv: " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul serves the KV endpoints of a Consul agent from memory, blocking queries included
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   int64
+	kvs     map[string]consulKV
+	changed chan struct{}
+	// requests the number of recursive list requests
+	requests int
+	// noIndex omits the X-Consul-Index header
+	noIndex bool
+	// failures answers the next requests with 500
+	failures int
+	// status answers list requests with the status when not 0
+	status int
+}
+
+func newFakeConsul(t *testing.T) (*fakeConsul, *httptest.Server) {
+	f := &fakeConsul{kvs: make(map[string]consulKV), changed: make(chan struct{})}
+	server := httptest.NewServer(http.HandlerFunc(f.handler))
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeConsul) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	f.kvs[key] = consulKV{Key: key, Value: []byte(value), ModifyIndex: f.index}
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) delete(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.index++
+	delete(f.kvs, key)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) handler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/txn" {
+		var ops []struct {
+			KV struct {
+				Verb  string
+				Key   string
+				Value []byte
+			}
+		}
+		_ = json.NewDecoder(r.Body).Decode(&ops)
+		for _, op := range ops {
+			if op.KV.Verb == "delete" {
+				f.delete(op.KV.Key)
+			} else {
+				f.put(op.KV.Key, string(op.KV.Value))
+			}
+		}
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	if r.Method == http.MethodPut {
+		b, _ := io.ReadAll(r.Body)
+		f.put(key, string(b))
+		return
+	}
+	index, _ := strconv.ParseInt(r.URL.Query().Get("index"), 10, 64)
+	f.mu.Lock()
+	f.requests++
+	if f.failures > 0 {
+		f.failures--
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if f.status != 0 {
+		f.mu.Unlock()
+		w.WriteHeader(f.status)
+		return
+	}
+	if index > 0 && index >= f.index && !f.noIndex {
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+	var kvs []consulKV
+	for k, kv := range f.kvs {
+		if strings.HasPrefix(k, key) {
+			kvs = append(kvs, kv)
+		}
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	if !f.noIndex {
+		w.Header().Set("X-Consul-Index", strconv.FormatInt(f.index, 10))
+	}
+	if len(kvs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(kvs)
+}
+
+func TestConsulWatchFromListIndex(t *testing.T) {
+	f, server := newFakeConsul(t)
+	f.put("app/db/host", "localhost")
+	f.put("app/db/user", "admin")
+	p := NewConsulProvider(server.URL, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kvs, index, err := p.List(ctx, "app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 2 || index != 2 {
+		t.Fatalf("List() = %v, %d, want two pairs at index 2", kvs, index)
+	}
+	// changed between List and Watch
+	f.delete("app/db/host")
+	f.put("app/db/port", "5432")
+	events, err := p.Watch(ctx, "app/", index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]EventType{}
+	for i := 0; i < 2; i++ {
+		event, _ := nextEvent(t, events)
+		got[event.KV.Key] = event.Type
+	}
+	want := map[string]EventType{"app/db/host": EventDelete, "app/db/port": EventPut}
+	for key, typ := range want {
+		if got[key] != typ {
+			t.Errorf("events = %v, want %v", got, want)
+			break
+		}
+	}
+	f.put("app/db/user", "root")
+	if event, _ := nextEvent(t, events); event.Type != EventPut || string(event.KV.Value) != "root" {
+		t.Errorf("event = %+v, want put of root", event)
+	}
+}
+
+func TestConsulWatchWithoutIndex(t *testing.T) {
+	f, server := newFakeConsul(t)
+	f.noIndex = true
+	f.put("app/n", "1")
+	p := NewConsulProvider(server.URL, nil, WithBackoff(20*time.Millisecond, 40*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, index, err := p.List(ctx, "app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := p.Watch(ctx, "app/", index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	f.mu.Lock()
+	requests := f.requests
+	f.mu.Unlock()
+	if requests > 10 {
+		t.Errorf("%d requests in 200ms without an index, want a delay between them", requests)
+	}
+	f.put("app/n", "2")
+	if event, _ := nextEvent(t, events); string(event.KV.Value) != "2" {
+		t.Errorf("event = %+v, want put of 2", event)
+	}
+}
+
+func TestConsulWatchRetry(t *testing.T) {
+	f, server := newFakeConsul(t)
+	f.put("app/n", "1")
+	p := NewConsulProvider(server.URL, nil, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, index, err := p.List(ctx, "app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	f.failures = 3
+	f.mu.Unlock()
+	events, err := p.Watch(ctx, "app/", index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.put("app/n", "2")
+	if event, _ := nextEvent(t, events); event.Type != EventPut || string(event.KV.Value) != "2" {
+		t.Errorf("event = %+v, want put of 2 after the failures", event)
+	}
+	f.mu.Lock()
+	f.status = http.StatusForbidden
+	f.mu.Unlock()
+	f.put("app/n", "3")
+	for {
+		event, ok := nextEvent(t, events)
+		if !ok {
+			t.Fatal("channel closed without an error event")
+		}
+		if event.Type == EventError {
+			break
+		}
+	}
+	if _, ok := nextEvent(t, events); ok {
+		t.Error("channel still open after an error event")
+	}
+}
+
+func TestConsulTxn(t *testing.T) {
+	f, server := newFakeConsul(t)
+	f.put("app/old", "1")
+	p := NewConsulProvider(server.URL, nil)
+	err := p.Txn(context.Background(), []Op{{Key: "/app/new", Value: []byte("2")}, {Key: "/app/old", Delete: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	if _, ok := f.kvs["app/old"]; ok || string(f.kvs["app/new"].Value) != "2" {
+		t.Errorf("store = %v, want only app/new = 2", f.kvs)
+	}
+	f.mu.Unlock()
+	if err := p.Txn(context.Background(), make([]Op, consulMaxTxnOps+1)); err == nil {
+		t.Error("Txn() above the operation limit succeeded")
+	}
+}
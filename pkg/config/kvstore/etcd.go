@@ -0,0 +1,286 @@
+package kvstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var _ Provider = (*EtcdProvider)(nil)
+var _ Transactor = (*EtcdProvider)(nil)
+
+// EtcdProvider speaks the etcd v3 JSON gRPC gateway API (`/v3/kv/range`, `/v3/kv/put`,
+// `/v3/kv/deleterange`, `/v3/kv/txn` and `/v3/watch`)
+type EtcdProvider struct {
+	endpoint string
+	client   *http.Client
+	opts     *options
+}
+
+// NewEtcdProvider new etcd v3 provider for the endpoint, e.g. `http://127.0.0.1:2379`
+func NewEtcdProvider(endpoint string, client *http.Client, opts ...Option) *EtcdProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &EtcdProvider{endpoint: strings.TrimSuffix(endpoint, "/"), client: client, opts: newOptions(opts)}
+}
+
+type etcdKV struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	ModRevision string `json:"mod_revision"`
+}
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []etcdKV   `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header          etcdHeader `json:"header"`
+		Canceled        bool       `json:"canceled"`
+		CancelReason    string     `json:"cancel_reason"`
+		CompactRevision string     `json:"compact_revision"`
+		Events          []struct {
+			Type string `json:"type"`
+			Kv   etcdKV `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Get returns the pair of the key
+func (p *EtcdProvider) Get(ctx context.Context, key string) (*KV, error) {
+	var resp etcdRangeResponse
+	if err := p.call(ctx, "/v3/kv/range", map[string]string{"key": encode(key)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	kv, err := resp.Kvs[0].decode()
+	if err != nil {
+		return nil, err
+	}
+	return &kv, nil
+}
+
+// List returns all pairs whose keys start with prefix and the revision of the store
+func (p *EtcdProvider) List(ctx context.Context, prefix string) ([]KV, int64, error) {
+	var resp etcdRangeResponse
+	req := map[string]string{"key": encode(prefix), "range_end": encode(prefixEnd(prefix))}
+	if err := p.call(ctx, "/v3/kv/range", req, &resp); err != nil {
+		return nil, 0, err
+	}
+	kvs := make([]KV, 0, len(resp.Kvs))
+	for _, ekv := range resp.Kvs {
+		kv, err := ekv.decode()
+		if err != nil {
+			return nil, 0, err
+		}
+		kvs = append(kvs, kv)
+	}
+	revision, _ := strconv.ParseInt(resp.Header.Revision, 10, 64)
+	return kvs, revision, nil
+}
+
+// Put creates or updates the key
+func (p *EtcdProvider) Put(ctx context.Context, key string, value []byte) error {
+	req := map[string]string{"key": encode(key), "value": base64.StdEncoding.EncodeToString(value)}
+	return p.call(ctx, "/v3/kv/put", req, nil)
+}
+
+// Delete deletes the key
+func (p *EtcdProvider) Delete(ctx context.Context, key string) error {
+	return p.call(ctx, "/v3/kv/deleterange", map[string]string{"key": encode(key)}, nil)
+}
+
+// Txn applies the operations in a single transaction without comparisons
+func (p *EtcdProvider) Txn(ctx context.Context, ops []Op) error {
+	success := make([]map[string]any, 0, len(ops))
+	for _, op := range ops {
+		if op.Delete {
+			success = append(success, map[string]any{"request_delete_range": map[string]string{"key": encode(op.Key)}})
+			continue
+		}
+		success = append(success, map[string]any{"request_put": map[string]string{
+			"key": encode(op.Key), "value": base64.StdEncoding.EncodeToString(op.Value),
+		}})
+	}
+	var resp struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	if err := p.call(ctx, "/v3/kv/txn", map[string]any{"success": success}, &resp); err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errors.New("etcd /v3/kv/txn: transaction failed")
+	}
+	return nil
+}
+
+// Watch watches the keys under prefix after the revision through the streaming watch API,
+// broken streams are reopened after the last seen revision
+func (p *EtcdProvider) Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error) {
+	body, err := p.watch(ctx, prefix, revision)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		b := newBackoff(p.opts)
+		for {
+			err := p.stream(ctx, body, events, &revision, b)
+			body.Close()
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				if _, fatal := err.(fatalError); fatal {
+					send(ctx, events, Event{Type: EventError, Err: err})
+					return
+				}
+				if !b.wait(ctx) {
+					return
+				}
+				if body, err = p.watch(ctx, prefix, revision); err == nil {
+					break
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// watch opens the watch stream of the keys under prefix changed after the revision
+func (p *EtcdProvider) watch(ctx context.Context, prefix string, revision int64) (io.ReadCloser, error) {
+	create := map[string]string{"key": encode(prefix), "range_end": encode(prefixEnd(prefix))}
+	if revision > 0 {
+		create["start_revision"] = strconv.FormatInt(revision+1, 10)
+	}
+	body, err := json.Marshal(map[string]any{"create_request": create})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v3/watch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if permanentStatus(resp.StatusCode) {
+			return nil, fatalError("etcd watch: " + resp.Status)
+		}
+		return nil, errors.New("etcd watch: " + resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// stream sends the events of the watch stream until it breaks, revision is advanced to the last seen revision
+func (p *EtcdProvider) stream(ctx context.Context, body io.Reader, events chan<- Event, revision *int64, b *backoff) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var wr etcdWatchResponse
+		if err := decoder.Decode(&wr); err != nil {
+			return errors.New("etcd watch: " + err.Error())
+		}
+		if wr.Error != nil {
+			return errors.New("etcd watch: " + wr.Error.Message)
+		}
+		if wr.Result.CompactRevision != "" && wr.Result.CompactRevision != "0" {
+			return fatalError("etcd watch: revision " + strconv.FormatInt(*revision+1, 10) + " has been compacted at " + wr.Result.CompactRevision)
+		}
+		if wr.Result.Canceled {
+			return fatalError("etcd watch canceled: " + wr.Result.CancelReason)
+		}
+		b.reset()
+		for _, e := range wr.Result.Events {
+			kv, err := e.Kv.decode()
+			if err != nil {
+				return fatalError("etcd watch: " + err.Error())
+			}
+			event := Event{Type: EventPut, KV: kv}
+			if e.Type == "DELETE" {
+				event.Type = EventDelete
+			}
+			if !send(ctx, events, event) {
+				return ctx.Err()
+			}
+			if kv.Revision > *revision {
+				*revision = kv.Revision
+			}
+		}
+	}
+}
+
+func (p *EtcdProvider) call(ctx context.Context, path string, request any, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("etcd " + path + ": " + resp.Status)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+func (kv etcdKV) decode() (KV, error) {
+	key, err := base64.StdEncoding.DecodeString(kv.Key)
+	if err != nil {
+		return KV{}, err
+	}
+	value, err := base64.StdEncoding.DecodeString(kv.Value)
+	if err != nil {
+		return KV{}, err
+	}
+	revision, _ := strconv.ParseInt(kv.ModRevision, 10, 64)
+	return KV{Key: string(key), Value: value, Revision: revision}, nil
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// prefixEnd returns the range end covering all keys with the prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// every key is greater or equal
+	return "\x00"
+}
@@ -0,0 +1,325 @@
+package kvstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd serves the range, put and watch endpoints of the etcd JSON gateway from memory
+type fakeEtcd struct {
+	mu      sync.Mutex
+	rev     int64
+	kvs     map[string]string
+	history []fakeEtcdEvent
+	changed chan struct{}
+	// starts the start revisions of the watch requests
+	starts []int64
+	// breakAfter closes watch streams after that many events, 0 keeps them open
+	breakAfter int
+	// compacted revisions up to it can not be watched anymore
+	compacted int64
+	// status answers watch requests with the status when not 0
+	status int
+	// cancelReason cancels watch requests when not empty
+	cancelReason string
+}
+
+type fakeEtcdEvent struct {
+	typ   string
+	key   string
+	value string
+	rev   int64
+}
+
+func newFakeEtcd(t *testing.T) (*fakeEtcd, *httptest.Server) {
+	f := &fakeEtcd{kvs: make(map[string]string), changed: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/kv/range", f.rangeHandler)
+	mux.HandleFunc("/v3/kv/put", f.putHandler)
+	mux.HandleFunc("/v3/kv/txn", f.txnHandler)
+	mux.HandleFunc("/v3/watch", f.watchHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+func (f *fakeEtcd) apply(typ, key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rev++
+	if typ == "DELETE" {
+		delete(f.kvs, key)
+	} else {
+		f.kvs[key] = value
+	}
+	f.history = append(f.history, fakeEtcdEvent{typ: typ, key: key, value: value, rev: f.rev})
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeEtcd) kv(key, value string, rev int64) map[string]string {
+	return map[string]string{
+		"key":          base64.StdEncoding.EncodeToString([]byte(key)),
+		"value":        base64.StdEncoding.EncodeToString([]byte(value)),
+		"mod_revision": strconv.FormatInt(rev, 10),
+	}
+}
+
+func (f *fakeEtcd) rangeHandler(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	kvs := []map[string]string{}
+	for key, value := range f.kvs {
+		kvs = append(kvs, f.kv(key, value, f.rev))
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"header": map[string]string{"revision": strconv.FormatInt(f.rev, 10)},
+		"kvs":    kvs,
+	})
+}
+
+func (f *fakeEtcd) putHandler(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	key, _ := base64.StdEncoding.DecodeString(req["key"])
+	value, _ := base64.StdEncoding.DecodeString(req["value"])
+	f.apply("PUT", string(key), string(value))
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (f *fakeEtcd) txnHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Success []struct {
+			RequestPut         map[string]string `json:"request_put"`
+			RequestDeleteRange map[string]string `json:"request_delete_range"`
+		} `json:"success"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	for _, op := range req.Success {
+		if op.RequestDeleteRange != nil {
+			key, _ := base64.StdEncoding.DecodeString(op.RequestDeleteRange["key"])
+			f.apply("DELETE", string(key), "")
+			continue
+		}
+		key, _ := base64.StdEncoding.DecodeString(op.RequestPut["key"])
+		value, _ := base64.StdEncoding.DecodeString(op.RequestPut["value"])
+		f.apply("PUT", string(key), string(value))
+	}
+	_, _ = w.Write([]byte(`{"succeeded":true}`))
+}
+
+func (f *fakeEtcd) watchHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CreateRequest struct {
+			StartRevision string `json:"start_revision"`
+		} `json:"create_request"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	start, _ := strconv.ParseInt(req.CreateRequest.StartRevision, 10, 64)
+	f.mu.Lock()
+	f.starts = append(f.starts, start)
+	if start == 0 {
+		start = f.rev + 1
+	}
+	status, compacted, cancelReason := f.status, f.compacted, f.cancelReason
+	f.mu.Unlock()
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	encoder := json.NewEncoder(w)
+	if cancelReason != "" {
+		_ = encoder.Encode(map[string]any{"result": map[string]any{"canceled": true, "cancel_reason": cancelReason}})
+		return
+	}
+	if start <= compacted {
+		_ = encoder.Encode(map[string]any{"result": map[string]any{
+			"canceled": true, "compact_revision": strconv.FormatInt(compacted, 10),
+		}})
+		return
+	}
+	_ = encoder.Encode(map[string]any{"result": map[string]any{"created": true}})
+	w.(http.Flusher).Flush()
+	sent := 0
+	for {
+		f.mu.Lock()
+		var events []map[string]any
+		for _, e := range f.history {
+			if e.rev >= start {
+				events = append(events, map[string]any{"type": e.typ, "kv": f.kv(e.key, e.value, e.rev)})
+				start = e.rev + 1
+			}
+		}
+		changed, breakAfter := f.changed, f.breakAfter
+		f.mu.Unlock()
+		for _, event := range events {
+			_ = encoder.Encode(map[string]any{"result": map[string]any{"events": []any{event}}})
+			w.(http.Flusher).Flush()
+			if sent++; breakAfter > 0 && sent >= breakAfter {
+				return
+			}
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// nextEvent returns the next event of the channel, the test fails after a second
+func nextEvent(t *testing.T, events <-chan Event) (Event, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}, false
+	}
+}
+
+func TestEtcdWatchFromListRevision(t *testing.T) {
+	f, server := newFakeEtcd(t)
+	f.apply("PUT", "/app/db/host", "localhost")
+	p := NewEtcdProvider(server.URL, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kvs, revision, err := p.List(ctx, "/app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || revision != 1 {
+		t.Fatalf("List() = %v, %d, want one pair at revision 1", kvs, revision)
+	}
+	// changed between List and Watch
+	f.apply("PUT", "/app/db/port", "5432")
+	f.apply("DELETE", "/app/db/host", "")
+	events, err := p.Watch(ctx, "/app/", revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		typ EventType
+		key string
+		rev int64
+	}{
+		{typ: EventPut, key: "/app/db/port", rev: 2},
+		{typ: EventDelete, key: "/app/db/host", rev: 3},
+	}
+	for _, tt := range tests {
+		event, _ := nextEvent(t, events)
+		if event.Type != tt.typ || event.KV.Key != tt.key || event.KV.Revision != tt.rev {
+			t.Errorf("event = %+v, want %v of %s at %d", event, tt.typ, tt.key, tt.rev)
+		}
+	}
+	if f.starts[0] != 2 {
+		t.Errorf("watch start_revision = %d, want 2", f.starts[0])
+	}
+}
+
+func TestEtcdWatchReconnect(t *testing.T) {
+	f, server := newFakeEtcd(t)
+	f.breakAfter = 1
+	p := NewEtcdProvider(server.URL, nil, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, revision, err := p.List(ctx, "/app/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := p.Watch(ctx, "/app/", revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		value := strconv.Itoa(i)
+		if err := p.Put(ctx, "/app/n", []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+		event, _ := nextEvent(t, events)
+		if string(event.KV.Value) != value {
+			t.Fatalf("event value = %q, want %q", event.KV.Value, value)
+		}
+	}
+	f.mu.Lock()
+	starts := f.starts
+	f.mu.Unlock()
+	if len(starts) < 3 || starts[1] != 2 || starts[2] != 3 {
+		t.Errorf("watch start revisions = %v, want reconnects resuming after the last event", starts)
+	}
+}
+
+func TestEtcdWatchFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *fakeEtcd)
+	}{
+		{name: "compacted", setup: func(f *fakeEtcd) { f.compacted = 5 }},
+		{name: "canceled", setup: func(f *fakeEtcd) { f.cancelReason = "permission denied" }},
+		{name: "forbidden", setup: func(f *fakeEtcd) { f.status = http.StatusForbidden }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, server := newFakeEtcd(t)
+			f.breakAfter = 1
+			p := NewEtcdProvider(server.URL, nil, WithBackoff(time.Millisecond, 10*time.Millisecond))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, err := p.Watch(ctx, "/app/", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.mu.Lock()
+			tt.setup(f)
+			f.mu.Unlock()
+			f.apply("PUT", "/app/n", "1")
+			if event, _ := nextEvent(t, events); event.Type != EventPut {
+				t.Fatalf("event = %+v, want put", event)
+			}
+			event, _ := nextEvent(t, events)
+			if event.Type != EventError || event.Err == nil {
+				t.Fatalf("event = %+v, want error", event)
+			}
+			if _, ok := nextEvent(t, events); ok {
+				t.Error("channel still open after an error event")
+			}
+		})
+	}
+}
+
+func TestEtcdWatchCancel(t *testing.T) {
+	_, server := newFakeEtcd(t)
+	p := NewEtcdProvider(server.URL, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := p.Watch(ctx, "/app/", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, ok := nextEvent(t, events); ok {
+		t.Error("channel still open after ctx is done")
+	}
+}
+
+func TestEtcdTxn(t *testing.T) {
+	f, server := newFakeEtcd(t)
+	f.apply("PUT", "/app/old", "1")
+	p := NewEtcdProvider(server.URL, nil)
+	err := p.Txn(context.Background(), []Op{{Key: "/app/new", Value: []byte("2")}, {Key: "/app/old", Delete: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.kvs["/app/old"]; ok || f.kvs["/app/new"] != "2" {
+		t.Errorf("store = %v, want only /app/new = 2", f.kvs)
+	}
+}
@@ -0,0 +1,94 @@
+package kvstore
+
+import (
+	"context"
+	"strings"
+)
+
+// KV is a key-value pair of the store
+type KV struct {
+	// Key full key of the pair
+	Key string
+	// Value raw value
+	Value []byte
+	// Revision store revision of the last modification
+	Revision int64
+}
+
+// EventType type of a watch event
+type EventType uint8
+
+const (
+	// EventPut the key has been created or updated
+	EventPut EventType = iota + 1
+	// EventDelete the key has been deleted
+	EventDelete
+	// EventError watching has failed for good, it is the last event before the channel is closed
+	EventError
+)
+
+// Event is a change of a key under the watched prefix
+type Event struct {
+	Type EventType
+	KV   KV
+	// Err the cause of an EventError
+	Err error
+}
+
+// Provider is the key-value store backend interface
+type Provider interface {
+	// Get returns the pair of the key, nil if the key does not exist
+	Get(ctx context.Context, key string) (*KV, error)
+	// List returns all pairs whose keys start with prefix and the store revision they have been read at
+	List(ctx context.Context, prefix string) ([]KV, int64, error)
+	// Put creates or updates the key
+	Put(ctx context.Context, key string, value []byte) error
+	// Delete deletes the key
+	Delete(ctx context.Context, key string) error
+	// Watch watches the keys under prefix for changes after the revision returned by List, so no
+	// change in between is lost. Broken connections are reestablished with backoff and resume after
+	// the last seen revision, failures which can not be recovered are sent as an EventError.
+	// The channel is closed when ctx is done or after an EventError.
+	Watch(ctx context.Context, prefix string, revision int64) (<-chan Event, error)
+}
+
+// Op is a put or a delete of a transaction
+type Op struct {
+	Key   string
+	Value []byte
+	// Delete deletes the key instead of putting the value
+	Delete bool
+}
+
+// Transactor is implemented by providers which apply several puts and deletes atomically
+type Transactor interface {
+	// Txn applies all operations or none of them
+	Txn(ctx context.Context, ops []Op) error
+}
+
+// Separator separator of key levels in the store
+const Separator = "/"
+
+// TrimPrefix returns the key relative to prefix split into its levels, empty levels are dropped
+func TrimPrefix(key, prefix string) []string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, Separator), strings.TrimPrefix(prefix, Separator))
+	var levels []string
+	for _, level := range strings.Split(key, Separator) {
+		if level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// fatalError a watch failure which reconnecting does not recover from
+type fatalError string
+
+func (e fatalError) Error() string {
+	return string(e)
+}
+
+// permanentStatus reports whether retrying a request answered with the HTTP status is pointless
+func permanentStatus(code int) bool {
+	return code >= 400 && code < 500 && code != 408 && code != 429
+}
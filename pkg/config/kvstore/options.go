@@ -0,0 +1,51 @@
+package kvstore
+
+import "time"
+
+const (
+	// DefaultMinBackoff the default delay before the first reconnect of a broken watch
+	DefaultMinBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff the default upper bound of the reconnect delay
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+type options struct {
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option option interface for key-value store providers
+type Option interface {
+	apply(opts *options)
+}
+
+// WithBackoff with backoff option, reconnect delays of broken watches start at min and double up to max
+func WithBackoff(min, max time.Duration) Option {
+	return backoffOption{min: min, max: max}
+}
+
+func newOptions(opts []Option) *options {
+	options := &options{
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	if options.minBackoff <= 0 {
+		options.minBackoff = DefaultMinBackoff
+	}
+	if options.maxBackoff < options.minBackoff {
+		options.maxBackoff = options.minBackoff
+	}
+	return options
+}
+
+type backoffOption struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (o backoffOption) apply(opts *options) {
+	opts.minBackoff, opts.maxBackoff = o.min, o.max
+}
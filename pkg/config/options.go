@@ -1,9 +1,13 @@
 package config
 
 import (
+	"crypto/ed25519"
 	"net/url"
+	"time"
 
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
 	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 )
 
@@ -39,6 +43,19 @@ type options struct {
 	sourceURL         *url.URL
 	encoder           configer.Encoder
 	decoder           configer.Decoder
+	codec             string
+	profile           string
+	decrypter         crypt.Decrypter
+	secretProviders   []secretProvider
+	secretTTL         *time.Duration
+	secretTimeout     *time.Duration
+	trustedKeys       []ed25519.PublicKey
+	errorHandler      func(err error)
+	customCodec       bool
+	reloadingSet      bool
+	validators        []Validator
+	normalizeKeys     bool
+	defaults          []defaultsLoader
 }
 
 // WithReloadingStrategy sets the reloading strategy for the config package.
@@ -46,7 +63,8 @@ func WithReloadingStrategy(strategy configer.ReloadingStrategy) Option {
 	return reloadingOption{strategy: strategy}
 }
 
-// WithSourceURL sets the source URL for the config package.
+// WithSourceURL sets the source URL for the config package. Non file URLs, or an empty file path,
+// load the configuration remotely from the URL, which is reloaded only by an explicit reloading strategy.
 func WithSourceURL(url *url.URL) Option {
 	return sourceOption{url: url}
 }
@@ -71,6 +89,66 @@ func WithDecoder(decoder configer.Decoder) Option {
 	return decoderOption{decoder: decoder}
 }
 
+// WithCodec sets the codec by its registered name or alias, overriding the detection by file extension
+// and Content-Type, `auto` detects the format from the content
+func WithCodec(name string) Option {
+	return codecOption(name)
+}
+
+// WithProfile sets the active profile of formats with profile documents such as multi-document YAML,
+// the documents of the profile are merged besides those without a profile
+func WithProfile(profile string) Option {
+	return profileOption(profile)
+}
+
+// WithDecrypter sets the decrypter of encrypted values `ENC[...]`, they are decrypted when read
+// and kept encrypted in the configuration, see crypt.NewAESGCM
+func WithDecrypter(decrypter crypt.Decrypter) Option {
+	return decrypterOption{decrypter: decrypter}
+}
+
+// WithSecretProvider registers the provider resolving secret references `secret://<name>/<path>#<field>`,
+// GetString returns the secret while Get returns it wrapped in secret.Value which redacts it when formatted
+func WithSecretProvider(name string, provider secret.Provider) Option {
+	return secretProviderOption{secretProvider{name: name, provider: provider}}
+}
+
+// WithSecretTTL sets the time resolved secrets are cached, secret.DefaultTTL by default,
+// secrets are resolved again after a reload
+func WithSecretTTL(ttl time.Duration) Option {
+	return secretTTLOption(ttl)
+}
+
+// WithSecretTimeout sets the time a provider has to resolve a secret when it is read,
+// secret.DefaultTimeout by default
+func WithSecretTimeout(timeout time.Duration) Option {
+	return secretTimeoutOption(timeout)
+}
+
+// WithTrustedKeys sets the keys the configuration file must be signed with, either by the detached
+// signature `<file>.sig` or by a signature embedded in the file, see sign.Detached and sign.Embed.
+// Unsigned or invalid documents are rejected on load and reload, saved files are not signed.
+func WithTrustedKeys(keys ...ed25519.PublicKey) Option {
+	return trustedKeysOption(keys)
+}
+
+// WithErrorHandler sets the handler of errors which happen in the background, e.g. when following
+// a key-value store fails. The configuration keeps its last values, errors are logged by default.
+func WithErrorHandler(handler func(err error)) Option {
+	return errorHandlerOption(handler)
+}
+
+// WithValidator adds a validator which is run against staged updates before they are committed
+func WithValidator(validator Validator) Option {
+	return validatorOption{validator: validator}
+}
+
+// WithNormalizedKeys makes key lookups case insensitive and ignores `_` and `-`,
+// so `maxConns`, `max_conns` and `MaxConns` refer to the same key
+func WithNormalizedKeys() Option {
+	return normalizedKeysOption(true)
+}
+
 type filePathOption string
 
 func (o filePathOption) apply(opts *options) {
@@ -88,6 +166,7 @@ type sourceOption struct {
 
 func (o reloadingOption) apply(opts *options) {
 	opts.reloadingStrategy = o.strategy
+	opts.reloadingSet = true
 }
 
 func (o sourceOption) apply(opts *options) {
@@ -108,6 +187,7 @@ type encoderOption struct {
 
 func (o encoderOption) apply(opts *options) {
 	opts.encoder = o.encoder
+	opts.customCodec = true
 }
 
 type decoderOption struct {
@@ -116,4 +196,98 @@ type decoderOption struct {
 
 func (o decoderOption) apply(opts *options) {
 	opts.decoder = o.decoder
+	opts.customCodec = true
+}
+
+type codecOption string
+
+func (o codecOption) apply(opts *options) {
+	opts.codec = string(o)
+}
+
+type profileOption string
+
+func (o profileOption) apply(opts *options) {
+	opts.profile = string(o)
+}
+
+type validatorOption struct {
+	validator Validator
+}
+
+func (o validatorOption) apply(opts *options) {
+	opts.validators = append(opts.validators, o.validator)
+}
+
+type normalizedKeysOption bool
+
+func (o normalizedKeysOption) apply(opts *options) {
+	opts.normalizeKeys = bool(o)
+}
+
+type decrypterOption struct {
+	decrypter crypt.Decrypter
+}
+
+func (o decrypterOption) apply(opts *options) {
+	opts.decrypter = o.decrypter
+}
+
+type secretProvider struct {
+	name     string
+	provider secret.Provider
+}
+
+type secretProviderOption struct {
+	secretProvider
+}
+
+func (o secretProviderOption) apply(opts *options) {
+	opts.secretProviders = append(opts.secretProviders, o.secretProvider)
+}
+
+type secretTTLOption time.Duration
+
+func (o secretTTLOption) apply(opts *options) {
+	ttl := time.Duration(o)
+	opts.secretTTL = &ttl
+}
+
+type secretTimeoutOption time.Duration
+
+func (o secretTimeoutOption) apply(opts *options) {
+	timeout := time.Duration(o)
+	opts.secretTimeout = &timeout
+}
+
+type trustedKeysOption []ed25519.PublicKey
+
+func (o trustedKeysOption) apply(opts *options) {
+	opts.trustedKeys = append(opts.trustedKeys, o...)
+}
+
+type errorHandlerOption func(err error)
+
+func (o errorHandlerOption) apply(opts *options) {
+	opts.errorHandler = o
+}
+
+type dumpOptions struct {
+	sources bool
+}
+
+// DumpOption option interface for Dump
+type DumpOption interface {
+	apply(opts *dumpOptions)
+}
+
+// WithoutSources dumps the values without their sources
+func WithoutSources() DumpOption {
+	return sourcesOption(false)
+}
+
+type sourcesOption bool
+
+func (o sourcesOption) apply(opts *dumpOptions) {
+	opts.sources = bool(o)
 }
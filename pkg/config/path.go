@@ -0,0 +1,370 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Segment is a single segment of a key path, either a key or an array index
+type Segment struct {
+	// Key section key, empty when the segment is an index
+	Key string
+	// Index array index, only valid when IsIndex is true
+	Index int
+	// IsIndex reports whether the segment is an array index
+	IsIndex bool
+}
+
+// Path is a parsed key path, it can be parsed once and reused for lookups.
+//
+// The grammar is a list of segments separated by `.`, each segment is a bare key,
+// a quoted key (`"k8s.io/name"`) or followed by array indexes (`servers[1]`).
+// Inside bare keys `\` escapes the next character.
+type Path []Segment
+
+// ParsePath parses the given key into a path
+func ParsePath(key string) (Path, error) {
+	p := &pathParser{src: key}
+	path, err := p.parse()
+	if err != nil {
+		return nil, errors.New("config key:`" + key + "` is invalid: " + err.Error())
+	}
+	return path, nil
+}
+
+// MustParsePath is like ParsePath but panics if the key can not be parsed
+func MustParsePath(key string) Path {
+	path, err := ParsePath(key)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// String returns the canonical form of the path which parses back to the same path
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, s := range p {
+		if s.IsIndex {
+			sb.WriteString("[" + strconv.Itoa(s.Index) + "]")
+			continue
+		}
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(quoteKey(s.Key))
+	}
+	return sb.String()
+}
+
+// Child returns a new path with the key appended
+func (p Path) Child(key string) Path {
+	child := make(Path, len(p), len(p)+1)
+	copy(child, p)
+	return append(child, Segment{Key: key})
+}
+
+func quoteKey(key string) string {
+	if key != "" && !strings.ContainsAny(key, ".[]\"'\\ ") {
+		return key
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range key {
+		if r == '"' || r == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+type pathParser struct {
+	src string
+	pos int
+}
+
+func (p *pathParser) parse() (Path, error) {
+	if p.src == "" {
+		return nil, errors.New("empty key")
+	}
+	var path Path
+	for {
+		if p.peek() != '[' {
+			key, err := p.key()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, Segment{Key: key})
+		}
+		for p.peek() == '[' {
+			index, err := p.index()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, Segment{Index: index, IsIndex: true})
+		}
+		if p.pos == len(p.src) {
+			return path, nil
+		}
+		if p.src[p.pos] != '.' {
+			return nil, errors.New("unexpected `" + string(p.src[p.pos]) + "` at " + strconv.Itoa(p.pos))
+		}
+		p.pos++
+		if p.pos == len(p.src) {
+			return nil, errors.New("trailing `.`")
+		}
+	}
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) key() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.quoted(c)
+	}
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == '[' {
+			break
+		}
+		if c == ']' || c == '"' || c == '\'' {
+			return "", errors.New("unexpected `" + string(c) + "` at " + strconv.Itoa(p.pos))
+		}
+		if c == '\\' {
+			p.pos++
+			if p.pos == len(p.src) {
+				return "", errors.New("unterminated escape")
+			}
+			c = p.src[p.pos]
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	if sb.Len() == 0 {
+		return "", errors.New("empty segment at " + strconv.Itoa(p.pos))
+	}
+	return sb.String(), nil
+}
+
+func (p *pathParser) quoted(quote byte) (string, error) {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		if c == quote {
+			return sb.String(), nil
+		}
+		if c == '\\' {
+			if p.pos == len(p.src) {
+				break
+			}
+			c = p.src[p.pos]
+			p.pos++
+		}
+		sb.WriteByte(c)
+	}
+	return "", errors.New("unterminated quote at " + strconv.Itoa(start))
+}
+
+func (p *pathParser) index() (int, error) {
+	start := p.pos
+	end := strings.IndexByte(p.src[start:], ']')
+	if end < 0 {
+		return 0, errors.New("unterminated index at " + strconv.Itoa(start))
+	}
+	index, err := strconv.Atoi(p.src[start+1 : start+end])
+	if err != nil || index < 0 {
+		return 0, errors.New("invalid index at " + strconv.Itoa(start))
+	}
+	p.pos = start + end + 1
+	return index, nil
+}
+
+// getPath returns the field at the path
+func getPath(configMap map[string]configer.Field, path Path) (configer.Field, bool) {
+	return findPath(configMap, path, false)
+}
+
+// findPath returns the field at the path, keys are compared after normalization if normalize is true
+func findPath(configMap map[string]configer.Field, path Path, normalize bool) (configer.Field, bool) {
+	if normalize {
+		path = resolvePath(configMap, path)
+	}
+	f := configer.Field{Type: configer.FieldTypeSection, Value: configMap}
+	for _, s := range path {
+		var ok bool
+		if f, ok = child(f, s); !ok {
+			return configer.Field{}, false
+		}
+	}
+	return f, true
+}
+
+// resolvePath replaces the key segments of the path with the existing keys
+// which are equal after normalization, unresolved segments are kept as is
+func resolvePath(configMap map[string]configer.Field, path Path) Path {
+	resolved := make(Path, len(path))
+	copy(resolved, path)
+	f := configer.Field{Type: configer.FieldTypeSection, Value: configMap}
+	for i, s := range resolved {
+		if !s.IsIndex && f.Type == configer.FieldTypeSection {
+			if _, ok := f.Value.(map[string]configer.Field)[s.Key]; !ok {
+				resolved[i].Key = matchKey(f.Value.(map[string]configer.Field), s.Key)
+			}
+		}
+		var ok bool
+		if f, ok = child(f, resolved[i]); !ok {
+			break
+		}
+	}
+	return resolved
+}
+
+// matchKey returns the smallest key of the map which normalizes to the same key, or key itself
+func matchKey(configMap map[string]configer.Field, key string) string {
+	normalized := normalizeKey(key)
+	match := key
+	found := false
+	for k := range configMap {
+		if normalizeKey(k) == normalized && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+	return match
+}
+
+func child(f configer.Field, s Segment) (configer.Field, bool) {
+	if !s.IsIndex {
+		if f.Type != configer.FieldTypeSection {
+			return configer.Field{}, false
+		}
+		v, ok := f.Value.(map[string]configer.Field)[s.Key]
+		return v, ok
+	}
+	rv := reflect.ValueOf(f.Value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return configer.Field{}, false
+	}
+	if s.Index >= rv.Len() {
+		return configer.Field{}, false
+	}
+	return configer.Atof(rv.Index(s.Index).Interface()), true
+}
+
+// setPath sets the field at the path, missing parent sections are created and
+// an index equal to the array length appends to the array
+func setPath(configMap map[string]configer.Field, path Path, value configer.Field) error {
+	if len(path) == 0 {
+		return errors.New("config key is empty")
+	}
+	_, err := updatePath(configer.Field{Type: configer.FieldTypeSection, Value: configMap}, path, func(configer.Field, bool) (configer.Field, bool, error) {
+		return value, true, nil
+	})
+	return err
+}
+
+// deletePath removes the field at the path and reports whether it existed
+func deletePath(configMap map[string]configer.Field, path Path) (bool, error) {
+	if len(path) == 0 {
+		return false, errors.New("config key is empty")
+	}
+	if _, ok := getPath(configMap, path); !ok {
+		return false, nil
+	}
+	_, err := updatePath(configer.Field{Type: configer.FieldTypeSection, Value: configMap}, path, func(configer.Field, bool) (configer.Field, bool, error) {
+		return configer.Field{}, false, nil
+	})
+	return err == nil, err
+}
+
+// updateFunc returns the new field of the leaf, keep is false to remove the leaf
+type updateFunc func(old configer.Field, exists bool) (f configer.Field, keep bool, err error)
+
+// updatePath applies fn to the leaf at the path and returns the updated parent field,
+// sections are updated in place while arrays are copied before they are modified
+func updatePath(f configer.Field, path Path, fn updateFunc) (configer.Field, error) {
+	s := path[0]
+	old, exists := child(f, s)
+	var updated configer.Field
+	keep := true
+	if len(path) == 1 {
+		var err error
+		if updated, keep, err = fn(old, exists); err != nil {
+			return f, err
+		}
+		if !keep && !exists {
+			return f, nil
+		}
+	} else {
+		if !exists {
+			if path[1].IsIndex {
+				return f, errors.New("config not found for key:`" + path[:1].String() + "`")
+			}
+			old = configer.Field{Type: configer.FieldTypeSection, Value: make(map[string]configer.Field)}
+		}
+		var err error
+		if updated, err = updatePath(old, path[1:], fn); err != nil {
+			return f, err
+		}
+	}
+	if !s.IsIndex {
+		if f.Type != configer.FieldTypeSection {
+			return f, errors.New("config key:`" + s.Key + "` parent is not a section")
+		}
+		if keep {
+			f.Value.(map[string]configer.Field)[s.Key] = updated
+		} else {
+			delete(f.Value.(map[string]configer.Field), s.Key)
+		}
+		return f, nil
+	}
+	return updateIndex(f, s.Index, updated, keep)
+}
+
+func updateIndex(f configer.Field, index int, updated configer.Field, keep bool) (configer.Field, error) {
+	rv := reflect.ValueOf(f.Value)
+	if rv.Kind() != reflect.Slice {
+		return f, errors.New("config field is not an array")
+	}
+	if index > rv.Len() || (index == rv.Len() && !keep) {
+		return f, errors.New("config array index " + strconv.Itoa(index) + " out of range")
+	}
+	elem := reflect.ValueOf(field.Ftoa(updated))
+	if keep {
+		if !elem.IsValid() || !elem.Type().AssignableTo(rv.Type().Elem()) {
+			return f, errors.New("config value type does not match array element type")
+		}
+	}
+	length := rv.Len()
+	if index == length {
+		length++
+	}
+	if !keep {
+		length--
+	}
+	arr := reflect.MakeSlice(rv.Type(), 0, length)
+	arr = reflect.AppendSlice(arr, rv.Slice(0, index))
+	if keep {
+		arr = reflect.Append(arr, elem)
+	}
+	if index < rv.Len() {
+		arr = reflect.AppendSlice(arr, rv.Slice(index+1, rv.Len()))
+	}
+	f.Value = arr.Interface()
+	return f, nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    Path
+		wantErr bool
+	}{
+		{key: "server", want: Path{{Key: "server"}}},
+		{key: "server.port", want: Path{{Key: "server"}, {Key: "port"}}},
+		{key: `labels."k8s.io/name"`, want: Path{{Key: "labels"}, {Key: "k8s.io/name"}}},
+		{key: `labels.'a"b'`, want: Path{{Key: "labels"}, {Key: `a"b`}}},
+		{key: `a\.b.c`, want: Path{{Key: "a.b"}, {Key: "c"}}},
+		{key: "servers[1].host", want: Path{{Key: "servers"}, {Index: 1, IsIndex: true}, {Key: "host"}}},
+		{key: "matrix[0][2]", want: Path{{Key: "matrix"}, {Index: 0, IsIndex: true}, {Index: 2, IsIndex: true}}},
+		{key: "[0]", want: Path{{Index: 0, IsIndex: true}}},
+		{key: "", wantErr: true},
+		{key: "a..b", wantErr: true},
+		{key: "a.", wantErr: true},
+		{key: `a."b`, wantErr: true},
+		{key: "a[x]", wantErr: true},
+		{key: "a[-1]", wantErr: true},
+		{key: "a[1", wantErr: true},
+		{key: "a]b", wantErr: true},
+		{key: `a\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := ParsePath(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePath(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePath(%q) = %#v, want %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path Path
+		want string
+	}{
+		{path: Path{{Key: "server"}, {Key: "port"}}, want: "server.port"},
+		{path: Path{{Key: "labels"}, {Key: "k8s.io/name"}}, want: `labels."k8s.io/name"`},
+		{path: Path{{Key: `a"b\`}}, want: `"a\"b\\"`},
+		{path: Path{{Key: "servers"}, {Index: 1, IsIndex: true}, {Key: "host"}}, want: "servers[1].host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.path.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+			parsed, err := ParsePath(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parsed, tt.path) {
+				t.Errorf("ParsePath(String()) = %#v, want %#v", parsed, tt.path)
+			}
+		})
+	}
+}
+
+func TestPathLookup(t *testing.T) {
+	c := newTestConfig(t, "config.toml", `
+[labels]
+"k8s.io/name" = "app"
+
+[[servers]]
+host = "a"
+
+[[servers]]
+host = "b"
+`)
+	tests := []struct {
+		key     string
+		want    any
+		wantErr bool
+	}{
+		{key: `labels."k8s.io/name"`, want: "app"},
+		{key: "servers[0].host", want: "a"},
+		{key: "servers[1].host", want: "b"},
+		{key: "servers[2].host", wantErr: true},
+		{key: "labels.missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := c.Get(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Get(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+	if err := c.Set("servers[1].host", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Get("servers[1].host"); got != "c" {
+		t.Errorf("Get() after Set = %v, want c", got)
+	}
+}
+
+func TestMergeSyncWatch(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "[server]\nhost = \"localhost\"\nport = 8080\n")
+	other := newTestConfig(t, "other.toml", "[server]\nport = 9090\n[log]\nlevel = \"debug\"\n")
+	if err := c.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"server": map[string]any{"host": "localhost", "port": int64(9090)},
+		"log":    map[string]any{"level": "debug"},
+	}
+	if got := c.AllSettings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllSettings() after Merge = %v, want %v", got, want)
+	}
+	b, err := os.ReadFile(c.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "debug") {
+		t.Errorf("merged file = %q, want merged values", b)
+	}
+	if err := os.Remove(c.FilePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.FilePath); err != nil {
+		t.Errorf("Sync() did not write the file: %v", err)
+	}
+	if err := c.Watch("server.port"); err != nil {
+		t.Errorf("Watch() error = %v", err)
+	}
+	if err := c.Watch("a..b"); err == nil {
+		t.Error("Watch() of invalid key succeeded")
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// DefaultName is the instance name under which the global DefaultConfig is available
+const DefaultName = "default"
+
+var (
+	registryMu sync.RWMutex
+	// registry named configuration instances
+	registry = make(map[string]configer.Configurable)
+)
+
+// Register registers the configuration under the given instance name
+func Register(name string, cfg configer.Configurable) error {
+	if name == "" {
+		return errors.New("config instance name is empty")
+	}
+	if cfg == nil {
+		return errors.New("config instance `" + name + "` is nil")
+	}
+	if name == DefaultName {
+		return errors.New("config instance name `" + DefaultName + "` is reserved")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		return errors.New("config instance `" + name + "` already registered")
+	}
+	registry[name] = cfg
+	return nil
+}
+
+// Unregister removes the configuration instance with the given name
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registry, name)
+}
+
+// Named returns the configuration instance registered under the given name,
+// DefaultName returns the global default configuration
+func Named(name string) (configer.Configurable, error) {
+	if name == DefaultName {
+		if err := Init(); err != nil {
+			return nil, errors.New("config instance `" + DefaultName + "` " + err.Error())
+		}
+		if cfg := L(); cfg != nil {
+			return cfg, nil
+		}
+		return nil, errors.New("config instance `" + DefaultName + "` not initialized")
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	cfg, ok := registry[name]
+	if !ok {
+		return nil, errors.New("config instance `" + name + "` not registered")
+	}
+	return cfg, nil
+}
+
+// Names returns the names of all registered configuration instances
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Reset resets the global default configuration and the registry, so Init can run again.
+// It is meant for tests and must not be called concurrently with Init.
+func Reset() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = make(map[string]configer.Configurable)
+	DefaultConfig = nil
+	once = sync.Once{}
+}
+
+// ContainsKeyFrom returns true if the key is in the named config
+func ContainsKeyFrom(name string, key string) bool {
+	cfg, err := Named(name)
+	if err != nil {
+		return false
+	}
+	return cfg.ContainsKey(key)
+}
+
+// GetStringFrom returns the string value of the key in the named config
+func GetStringFrom(name string, key string) (string, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return "", err
+	}
+	return cfg.GetString(key)
+}
+
+// GetStringSliceFrom returns the string slice value of the key in the named config
+func GetStringSliceFrom(name string, key string) ([]string, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetStringSlice(key)
+}
+
+// GetBoolFrom returns the bool value of the key in the named config
+func GetBoolFrom(name string, key string) (bool, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return false, err
+	}
+	return cfg.GetBool(key)
+}
+
+// GetBoolSliceFrom returns the bool slice value of the key in the named config
+func GetBoolSliceFrom(name string, key string) ([]bool, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetBoolSlice(key)
+}
+
+// GetIntFrom returns the int value of the key in the named config
+func GetIntFrom(name string, key string) (int, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetInt(key)
+}
+
+// GetIntSliceFrom returns the int slice value of the key in the named config
+func GetIntSliceFrom(name string, key string) ([]int, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetIntSlice(key)
+}
+
+// GetInt32From returns the int32 value of the key in the named config
+func GetInt32From(name string, key string) (int32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetInt32(key)
+}
+
+// GetInt32SliceFrom returns the int32 slice value of the key in the named config
+func GetInt32SliceFrom(name string, key string) ([]int32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetInt32Slice(key)
+}
+
+// GetInt64From returns the int64 value of the key in the named config
+func GetInt64From(name string, key string) (int64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetInt64(key)
+}
+
+// GetInt64SliceFrom returns the int64 slice value of the key in the named config
+func GetInt64SliceFrom(name string, key string) ([]int64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetInt64Slice(key)
+}
+
+// GetUintFrom returns the uint value of the key in the named config
+func GetUintFrom(name string, key string) (uint, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetUint(key)
+}
+
+// GetUintSliceFrom returns the uint slice value of the key in the named config
+func GetUintSliceFrom(name string, key string) ([]uint, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetUintSlice(key)
+}
+
+// GetUint32From returns the uint32 value of the key in the named config
+func GetUint32From(name string, key string) (uint32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetUint32(key)
+}
+
+// GetUint32SliceFrom returns the uint32 slice value of the key in the named config
+func GetUint32SliceFrom(name string, key string) ([]uint32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetUint32Slice(key)
+}
+
+// GetUint64From returns the uint64 value of the key in the named config
+func GetUint64From(name string, key string) (uint64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetUint64(key)
+}
+
+// GetUint64SliceFrom returns the uint64 slice value of the key in the named config
+func GetUint64SliceFrom(name string, key string) ([]uint64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetUint64Slice(key)
+}
+
+// GetFloat32From returns the float32 value of the key in the named config
+func GetFloat32From(name string, key string) (float32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetFloat32(key)
+}
+
+// GetFloat32SliceFrom returns the float32 slice value of the key in the named config
+func GetFloat32SliceFrom(name string, key string) ([]float32, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetFloat32Slice(key)
+}
+
+// GetFloat64From returns the float64 value of the key in the named config
+func GetFloat64From(name string, key string) (float64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetFloat64(key)
+}
+
+// GetFloat64SliceFrom returns the float64 slice value of the key in the named config
+func GetFloat64SliceFrom(name string, key string) ([]float64, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetFloat64Slice(key)
+}
+
+// GetDurationFrom returns the duration value of the key in the named config
+func GetDurationFrom(name string, key string) (time.Duration, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.GetDuration(key)
+}
+
+// GetTimeFrom returns the time value of the key in the named config
+func GetTimeFrom(name string, key string) (time.Time, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cfg.GetTime(key)
+}
+
+// GetSectionFrom returns the section value of the key in the named config
+func GetSectionFrom(name string, key string) (configer.Configurable, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetSection(key)
+}
+
+// GetFrom returns the value of the key in the named config
+func GetFrom(name string, key string) (any, error) {
+	cfg, err := Named(name)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Get(key)
+}
+
+// SetTo sets the value of the key in the named config
+func SetTo(name string, key string, value any) error {
+	cfg, err := Named(name)
+	if err != nil {
+		return err
+	}
+	return cfg.Set(key, value)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+func TestRegister(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	cfg := newTestConfig(t, "config.toml", "name = \"db\"\n")
+	tests := []struct {
+		name    string
+		cfg     configer.Configurable
+		wantErr bool
+	}{
+		{name: "db", cfg: cfg},
+		{name: "db", cfg: cfg, wantErr: true},
+		{name: "", cfg: cfg, wantErr: true},
+		{name: DefaultName, cfg: cfg, wantErr: true},
+		{name: "nil", cfg: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := Register(tt.name, tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("Register(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if got, err := GetStringFrom("db", "name"); err != nil || got != "db" {
+		t.Errorf("GetStringFrom() = %q, %v, want db", got, err)
+	}
+	if err := SetTo("db", "name", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if !ContainsKeyFrom("db", "name") || ContainsKeyFrom("missing", "name") {
+		t.Error("ContainsKeyFrom() reports wrong instances")
+	}
+	if names := Names(); len(names) != 1 || names[0] != "db" {
+		t.Errorf("Names() = %v, want [db]", names)
+	}
+	Unregister("db")
+	if _, err := Named("db"); err == nil {
+		t.Error("Named() of an unregistered instance succeeded")
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	first := newTestConfig(t, "first.toml", "name = \"first\"\n")
+	if err := Init(WithConfigurable(first)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register("db", first); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Named(DefaultName); err != nil || got != first {
+		t.Errorf("Named(DefaultName) = %v, %v, want the default config", got, err)
+	}
+	Reset()
+	if len(Names()) != 0 {
+		t.Errorf("Names() after Reset = %v, want none", Names())
+	}
+	second := newTestConfig(t, "second.toml", "name = \"second\"\n")
+	if err := Init(WithConfigurable(second)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := GetStringFrom(DefaultName, "name"); err != nil || got != "second" {
+		t.Errorf("GetStringFrom(DefaultName) after Reset = %q, %v, want second", got, err)
+	}
+}
+
+func TestNamedDefaultFailed(t *testing.T) {
+	t.Cleanup(Reset)
+	Reset()
+	// the default config failed to initialize
+	once.Do(func() {})
+	if cfg, err := Named(DefaultName); err == nil || cfg != nil {
+		t.Errorf("Named(DefaultName) = %v, %v, want an error", cfg, err)
+	}
+	if _, err := GetStringFrom(DefaultName, "name"); err == nil {
+		t.Error("GetStringFrom(DefaultName) succeeded")
+	}
+	if ContainsKeyFrom(DefaultName, "name") {
+		t.Error("ContainsKeyFrom(DefaultName) = true, want false")
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// newSecretResolver returns the resolver of the registered secret providers, nil if there are none
+func newSecretResolver(options *options) *secret.Resolver {
+	if len(options.secretProviders) == 0 {
+		return nil
+	}
+	var opts []secret.Option
+	if options.secretTTL != nil {
+		opts = append(opts, secret.WithTTL(*options.secretTTL))
+	}
+	if options.secretTimeout != nil {
+		opts = append(opts, secret.WithTimeout(*options.secretTimeout))
+	}
+	resolver := secret.NewResolver(opts...)
+	for _, p := range options.secretProviders {
+		resolver.Register(p.name, p.provider)
+	}
+	return resolver
+}
+
+// resolveSecret returns the secret of a secret reference wrapped in secret.Value, other fields are
+// returned as is. It is called without holding the lock as providers may be slow.
+func resolveSecret(secrets *secret.Resolver, f configer.Field) (configer.Field, error) {
+	s, ok := f.Value.(string)
+	if secrets == nil || !ok || !secret.IsRef(s) {
+		return f, nil
+	}
+	v, err := secrets.Resolve(context.Background(), s)
+	if err != nil {
+		return configer.Field{}, err
+	}
+	return configer.Field{Type: configer.FieldTypeString, Value: secret.Value(v)}, nil
+}
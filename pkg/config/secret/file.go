@@ -0,0 +1,40 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider resolves secrets from files under its root, such as Docker and Kubernetes secrets.
+// `secret://file/run/secrets/db` reads `/run/secrets/db` with the root `/`, a field selects
+// a field of a JSON file. The trailing newline is trimmed, paths escaping the root are rejected.
+type FileProvider struct {
+	root string
+}
+
+// NewFileProvider new file provider reading the files under root, `/` if empty
+func NewFileProvider(root string) *FileProvider {
+	if root == "" {
+		root = "/"
+	}
+	return &FileProvider{root: filepath.Clean(root)}
+}
+
+// Resolve reads the secret of the reference
+func (p *FileProvider) Resolve(ctx context.Context, ref Ref) (string, error) {
+	name := filepath.Join(p.root, filepath.FromSlash(ref.Path))
+	if rel, err := filepath.Rel(p.root, name); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("secret path `" + ref.Path + "` is outside of the root")
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	if ref.Field != "" {
+		return selectField(b, ref.Field)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,57 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "run")
+	if err := os.MkdirAll(filepath.Join(root, "secrets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(root, "secrets", "db"):   "s3cret\n",
+		filepath.Join(root, "secrets", "json"): `{"db":{"password":"p4ss"}}`,
+		filepath.Join(dir, "outside"):          "leaked",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := NewFileProvider(root)
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "secret://file/secrets/db", want: "s3cret"},
+		{ref: "secret://file/secrets/json#db.password", want: "p4ss"},
+		{ref: "secret://file/secrets/../secrets/db", want: "s3cret"},
+		{ref: "secret://file/../outside", wantErr: true},
+		{ref: "secret://file/secrets/../../outside", wantErr: true},
+		{ref: "secret://file/%2e%2e/outside", wantErr: true},
+		{ref: "secret://file/secrets/%2E%2E/%2e%2e/outside", wantErr: true},
+		{ref: "secret://file/..", wantErr: true},
+		{ref: "secret://file/secrets/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref, err := ParseRef(tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Resolve(context.Background(), ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() = %q, error = %v, wantErr %v", got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
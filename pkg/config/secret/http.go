@@ -0,0 +1,72 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPProvider resolves secrets with GET requests to the path under its base URL, such as the
+// Vault HTTP API: `secret://vault/v1/secret/data/db#data.data.password` with the base URL
+// `https://vault:8200` and the `X-Vault-Token` header. A field selects a field of the JSON
+// response, nested fields are separated by `.`, otherwise the trimmed body is the secret.
+type HTTPProvider struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+}
+
+// NewHTTPProvider new HTTP provider requesting the paths under the base URL
+func NewHTTPProvider(baseURL string, opts ...HTTPOption) *HTTPProvider {
+	options := &httpOptions{
+		client: &http.Client{Timeout: 30 * time.Second},
+		header: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	return &HTTPProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  options.client,
+		header:  options.header,
+	}
+}
+
+// Resolve requests the secret of the reference, the path stays under the base URL: `..` segments
+// are rejected and the segments are escaped
+func (p *HTTPProvider) Resolve(ctx context.Context, ref Ref) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(ref.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment == ".." {
+			return "", errors.New("secret path `" + ref.Path + "` is outside of the base URL")
+		}
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range p.header {
+		req.Header[key] = values
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("get " + ref.Path + ": " + resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if ref.Field != "" {
+		return selectField(b, ref.Field)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,63 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/v1/secret/data/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"p4ss"}}}`))
+		case "/v1/secret/plain":
+			_, _ = w.Write([]byte("s3cret\n"))
+		case "/v1/secret/a%3Fb%23c":
+			_, _ = w.Write([]byte("escaped"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	p := NewHTTPProvider(server.URL+"/v1/", WithHeader("X-Vault-Token", "token"))
+	tests := []struct {
+		name    string
+		ref     Ref
+		want    string
+		wantErr bool
+	}{
+		{name: "field", ref: Ref{Path: "secret/data/db", Field: "data.data.password"}, want: "p4ss"},
+		{name: "body", ref: Ref{Path: "secret/plain"}, want: "s3cret"},
+		{name: "escaped", ref: Ref{Path: "secret/a?b#c"}, want: "escaped"},
+		{name: "parent", ref: Ref{Path: "../sys/seal"}, wantErr: true},
+		{name: "nested parent", ref: Ref{Path: "secret/../../sys/seal"}, wantErr: true},
+		{name: "encoded parent", ref: mustParseRef(t, "secret://vault/%2e%2e/sys/seal"), wantErr: true},
+		{name: "missing", ref: Ref{Path: "secret/missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Resolve(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() = %q, error = %v, wantErr %v", got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustParseRef(t *testing.T, value string) Ref {
+	t.Helper()
+	ref, err := ParseRef(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"net/http"
+	"time"
+)
+
+type options struct {
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+// Option option interface for the resolver
+type Option interface {
+	apply(opts *options)
+}
+
+// WithTTL with the time resolved secrets are cached, secrets are cached until refreshed if ttl <= 0
+func WithTTL(ttl time.Duration) Option {
+	return ttlOption(ttl)
+}
+
+// WithTimeout with the time a provider has to resolve a secret, it is not bounded if timeout <= 0
+func WithTimeout(timeout time.Duration) Option {
+	return timeoutOption(timeout)
+}
+
+type ttlOption time.Duration
+
+func (o ttlOption) apply(opts *options) {
+	opts.ttl = time.Duration(o)
+}
+
+type timeoutOption time.Duration
+
+func (o timeoutOption) apply(opts *options) {
+	opts.timeout = time.Duration(o)
+}
+
+type httpOptions struct {
+	client *http.Client
+	header http.Header
+}
+
+// HTTPOption option interface for the HTTP provider
+type HTTPOption interface {
+	apply(opts *httpOptions)
+}
+
+// WithHeader with a header sent with every request, such as `X-Vault-Token`
+func WithHeader(key, value string) HTTPOption {
+	return headerOption{key: key, value: value}
+}
+
+// WithHTTPClient with the HTTP client of the provider
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return clientOption{client: client}
+}
+
+type headerOption struct {
+	key   string
+	value string
+}
+
+func (o headerOption) apply(opts *httpOptions) {
+	opts.header.Add(o.key, o.value)
+}
+
+type clientOption struct {
+	client *http.Client
+}
+
+func (o clientOption) apply(opts *httpOptions) {
+	opts.client = o.client
+}
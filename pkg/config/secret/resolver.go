@@ -0,0 +1,94 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultTTL the default time resolved secrets are cached
+const DefaultTTL = 5 * time.Minute
+
+// DefaultTimeout the default time a provider has to resolve a secret
+const DefaultTimeout = 10 * time.Second
+
+// Resolver resolves secret references through the registered providers and caches the secrets,
+// it is safe for concurrent use
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	ttl       time.Duration
+	timeout   time.Duration
+	cache     map[string]entry
+	now       func() time.Time
+}
+
+// entry a cached secret
+type entry struct {
+	value   string
+	expires time.Time
+}
+
+// NewResolver new resolver, secrets are cached for DefaultTTL unless WithTTL is given
+// and resolved within DefaultTimeout unless WithTimeout is given
+func NewResolver(opts ...Option) *Resolver {
+	options := &options{ttl: DefaultTTL, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	return &Resolver{
+		providers: make(map[string]Provider),
+		ttl:       options.ttl,
+		timeout:   options.timeout,
+		cache:     make(map[string]entry),
+		now:       time.Now,
+	}
+}
+
+// Register registers the provider for references naming it
+func (r *Resolver) Register(name string, provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// Resolve returns the secret of the reference, from the cache while it has not expired
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	r.mu.RLock()
+	cached, ok := r.cache[value]
+	r.mu.RUnlock()
+	if ok && (r.ttl <= 0 || r.now().Before(cached.expires)) {
+		return cached.value, nil
+	}
+	ref, err := ParseRef(value)
+	if err != nil {
+		return "", err
+	}
+	r.mu.RLock()
+	provider, ok := r.providers[ref.Provider]
+	r.mu.RUnlock()
+	if !ok {
+		return "", errors.New("secret provider `" + ref.Provider + "` not registered")
+	}
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	secret, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", errors.New("resolve secret `" + ref.String() + "`: " + err.Error())
+	}
+	r.mu.Lock()
+	r.cache[value] = entry{value: secret, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// Refresh drops the cached secrets, they are resolved again on next use
+func (r *Resolver) Refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[string]entry)
+}
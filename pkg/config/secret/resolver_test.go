@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	calls := 0
+	provider := ProviderFunc(func(ctx context.Context, ref Ref) (string, error) {
+		calls++
+		switch ref.Path {
+		case "slow":
+			<-ctx.Done()
+			return "", ctx.Err()
+		case "fail":
+			return "", errors.New("unavailable")
+		}
+		return ref.Path + "#" + ref.Field, nil
+	})
+	now := time.Unix(0, 0)
+	r := NewResolver(WithTTL(time.Minute), WithTimeout(10*time.Millisecond))
+	r.now = func() time.Time { return now }
+	r.Register("test", provider)
+	tests := []struct {
+		name      string
+		ref       string
+		advance   time.Duration
+		want      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "resolved", ref: "secret://test/db#password", want: "db#password", wantCalls: 1},
+		{name: "cached", ref: "secret://test/db#password", want: "db#password", wantCalls: 1},
+		{name: "expired", ref: "secret://test/db#password", advance: 2 * time.Minute, want: "db#password", wantCalls: 2},
+		{name: "timeout", ref: "secret://test/slow", wantCalls: 3, wantErr: true},
+		{name: "provider error", ref: "secret://test/fail", wantCalls: 4, wantErr: true},
+		{name: "unregistered provider", ref: "secret://vault/db", wantCalls: 4, wantErr: true},
+		{name: "no path", ref: "secret://test", wantCalls: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			got, err := r.Resolve(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() = %q, error = %v, wantErr %v", got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("provider calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Scheme the URL scheme of secret references
+const Scheme = "secret"
+
+// Redacted the text secrets are replaced with when formatted
+const Redacted = "******"
+
+// Provider resolves the secrets of references naming it
+type Provider interface {
+	// Resolve returns the secret of the reference
+	Resolve(ctx context.Context, ref Ref) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context, ref Ref) (string, error)
+
+// Resolve returns the secret of the reference
+func (f ProviderFunc) Resolve(ctx context.Context, ref Ref) (string, error) {
+	return f(ctx, ref)
+}
+
+// Ref a secret reference `secret://<provider>/<path>#<field>`, such as `secret://vault/db/creds#password`
+// or `secret://file/run/secrets/db`, the field selects a field of a structured secret
+type Ref struct {
+	Provider string
+	Path     string
+	Field    string
+}
+
+// IsRef reports whether the value is a secret reference
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, Scheme+"://")
+}
+
+// ParseRef parses the secret reference
+func ParseRef(value string) (Ref, error) {
+	if !IsRef(value) {
+		return Ref{}, errors.New("value is not a secret reference")
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return Ref{}, err
+	}
+	if u.Host == "" {
+		return Ref{}, errors.New("secret reference `" + value + "` has no provider")
+	}
+	ref := Ref{Provider: u.Host, Path: strings.TrimPrefix(u.Path, "/"), Field: u.Fragment}
+	if ref.Path == "" {
+		return Ref{}, errors.New("secret reference `" + value + "` has no path")
+	}
+	return ref, nil
+}
+
+// String returns the secret reference
+func (r Ref) String() string {
+	s := Scheme + "://" + r.Provider + "/" + r.Path
+	if r.Field != "" {
+		s += "#" + r.Field
+	}
+	return s
+}
+
+// Value a resolved secret which is redacted when formatted or marshaled, Reveal returns the secret
+type Value string
+
+// Reveal returns the secret
+func (v Value) Reveal() string {
+	return string(v)
+}
+
+// String returns the redacted secret
+func (v Value) String() string {
+	return Redacted
+}
+
+// GoString returns the redacted secret
+func (v Value) GoString() string {
+	return Redacted
+}
+
+// Format formats the redacted secret for every verb
+func (v Value) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, Redacted)
+}
+
+// MarshalText marshals the redacted secret
+func (v Value) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// MarshalJSON marshals the redacted secret
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// selectField returns the field of the JSON object, nested fields are separated by `.`
+func selectField(b []byte, name string) (string, error) {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", errors.New("secret is not a JSON object: " + err.Error())
+	}
+	for _, key := range strings.Split(name, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return "", errors.New("secret field `" + name + "` not found")
+		}
+		if v, ok = m[key]; !ok {
+			return "", errors.New("secret field `" + name + "` not found")
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case map[string]any, []any, nil:
+		return "", errors.New("secret field `" + name + "` is not a scalar")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
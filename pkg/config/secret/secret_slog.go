@@ -0,0 +1,10 @@
+//go:build go1.21
+
+package secret
+
+import "log/slog"
+
+// LogValue returns the redacted secret for structured logging
+func (v Value) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+)
+
+func TestSecretReferences(t *testing.T) {
+	provider := secret.ProviderFunc(func(ctx context.Context, ref secret.Ref) (string, error) {
+		if ref.Path == "slow" {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "value of " + ref.Path, nil
+	})
+	c := newTestConfig(t, "config.toml", "password = \"secret://test/db\"\nslow = \"secret://test/slow\"\nplain = \"text\"\n",
+		WithSecretProvider("test", provider), WithSecretTimeout(10*time.Millisecond))
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "password", want: "value of db"},
+		{key: "plain", want: "text"},
+		{key: "slow", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := c.GetString(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetString(%q) = %q, error = %v, wantErr %v", tt.key, got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetString(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+	v, err := c.Get("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(v); s != secret.Redacted {
+		t.Errorf("Get() formatted = %q, want the secret redacted", s)
+	}
+}
+
+func TestSecretResolvedWithoutLock(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	provider := secret.ProviderFunc(func(ctx context.Context, ref secret.Ref) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "s3cret", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+	c := newTestConfig(t, "config.toml", "password = \"secret://test/db\"\nport = 8080\n", WithSecretProvider("test", provider))
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetString("password")
+		done <- err
+	}()
+	<-started
+	updated := make(chan error, 1)
+	go func() {
+		updated <- c.Set("port", int64(8081))
+	}()
+	select {
+	case err := <-updated:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Set() blocked while a secret was resolved")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"errors"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DefaultSensitivePatterns the patterns of the keys which are masked by default
+var DefaultSensitivePatterns = []string{
+	"*password*",
+	"*passwd*",
+	"*secret*",
+	"*token*",
+	"*apikey*",
+	"*api_key*",
+	"*private_key*",
+	"*credential*",
+}
+
+var (
+	sensitiveMu sync.RWMutex
+	// sensitivePatterns the patterns of keys marked sensitive
+	sensitivePatterns []string
+)
+
+// MarkSensitive marks the keys matching the patterns as sensitive, their values are masked by Dump
+// and when formatted through Value. Patterns use path.Match syntax and are matched case insensitively
+// against the whole key path and its last key, `db.dsn` and `*password*` both match `db.password`.
+func MarkSensitive(patterns ...string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.New("sensitive key pattern `" + pattern + "` is invalid")
+		}
+	}
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	for _, pattern := range patterns {
+		sensitivePatterns = append(sensitivePatterns, strings.ToLower(pattern))
+	}
+	return nil
+}
+
+// MarkSensitiveStruct marks the keys of the struct fields tagged `config:"<key>,sensitive"` as
+// sensitive, nested structs are walked with their keys appended to prefix
+func MarkSensitiveStruct(prefix string, v any) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return errors.New("sensitive keys must be marked by a struct")
+	}
+	var base Path
+	if prefix != "" {
+		var err error
+		if base, err = ParsePath(prefix); err != nil {
+			return err
+		}
+	}
+	var keys []string
+	collectSensitive(t, base, &keys)
+	return MarkSensitive(keys...)
+}
+
+// collectSensitive collects the escaped key paths of the sensitive fields of the struct type
+func collectSensitive(t reflect.Type, prefix Path, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("config")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = f.Name
+		}
+		key := prefix.Child(name)
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "sensitive" {
+				*keys = append(*keys, escapePattern(key.String()))
+			}
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			collectSensitive(ft, key, keys)
+		}
+	}
+}
+
+// escapePattern escapes the path.Match meta characters of the key path
+func escapePattern(key string) string {
+	var sb strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// IsSensitive reports whether the key matches a default or marked sensitive pattern
+func IsSensitive(key string) bool {
+	p, err := ParsePath(key)
+	if err != nil {
+		return false
+	}
+	return isSensitive(p)
+}
+
+func isSensitive(p Path) bool {
+	full := strings.ToLower(p.String())
+	last := ""
+	for i := len(p) - 1; i >= 0; i-- {
+		if !p[i].IsIndex {
+			last = strings.ToLower(p[i].Key)
+			break
+		}
+	}
+	sensitiveMu.RLock()
+	patterns := append(append([]string(nil), DefaultSensitivePatterns...), sensitivePatterns...)
+	sensitiveMu.RUnlock()
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if ok, _ := path.Match(pattern, full); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, last); ok && last != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// GenerateKey generates a key pair encoded in base64, the private key is its 32 bytes seed
+func GenerateKey() (publicKey string, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv.Seed()), nil
+}
+
+// ParsePublicKey parses a raw, base64 or hex encoded public key
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	key, ok := decodeKey(b, ed25519.PublicKeySize)
+	if !ok {
+		return nil, errors.New("public key must be 32 bytes, raw or encoded in base64 or hex")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ParsePrivateKey parses a raw, base64 or hex encoded private key or its seed
+func ParsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	// the hex encoded seed has the size of the raw private key
+	if seed, ok := decodeKey(b, ed25519.SeedSize); ok {
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if key, ok := decodeKey(b, ed25519.PrivateKeySize); ok {
+		return ed25519.PrivateKey(key), nil
+	}
+	return nil, errors.New("private key must be a 64 bytes key or 32 bytes seed, raw or encoded in base64 or hex")
+}
+
+// decodeKey decodes a base64 or hex encoded key of the size, otherwise takes b as the raw key
+func decodeKey(b []byte, size int) ([]byte, bool) {
+	text := string(bytes.TrimSpace(b))
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == size {
+		return key, true
+	}
+	if key, err := hex.DecodeString(text); err == nil && len(key) == size {
+		return key, true
+	}
+	if len(b) == size {
+		return b, true
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	key := testKey(3)
+	pub := key.Public().(ed25519.PublicKey)
+	tests := []struct {
+		name    string
+		parse   func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{name: "public raw", parse: func() (any, error) { return ParsePublicKey(pub) }, want: pub},
+		{name: "public base64", parse: func() (any, error) { return ParsePublicKey([]byte(base64.StdEncoding.EncodeToString(pub) + "\n")) }, want: pub},
+		{name: "public hex", parse: func() (any, error) { return ParsePublicKey([]byte(hex.EncodeToString(pub))) }, want: pub},
+		{name: "public too short", parse: func() (any, error) { return ParsePublicKey(pub[:16]) }, wantErr: true},
+		{name: "private seed base64", parse: func() (any, error) { return ParsePrivateKey([]byte(base64.StdEncoding.EncodeToString(key.Seed()))) }, want: key},
+		{name: "private seed hex", parse: func() (any, error) { return ParsePrivateKey([]byte(hex.EncodeToString(key.Seed()))) }, want: key},
+		{name: "private seed raw", parse: func() (any, error) { return ParsePrivateKey(key.Seed()) }, want: key},
+		{name: "private raw", parse: func() (any, error) { return ParsePrivateKey(key) }, want: key},
+		{name: "private base64", parse: func() (any, error) { return ParsePrivateKey([]byte(base64.StdEncoding.EncodeToString(key))) }, want: key},
+		{name: "private invalid", parse: func() (any, error) { return ParsePrivateKey([]byte("key")) }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !bytes.Equal(toBytes(got), toBytes(tt.want)) {
+				t.Errorf("parse = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey([]byte(publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("a = 1\n")
+	if _, err := Verify([]ed25519.PublicKey{pub}, payload, Detached(priv, payload)); err != nil {
+		t.Errorf("Verify() with the generated keys error = %v", err)
+	}
+}
+
+func toBytes(key any) []byte {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k
+	case ed25519.PrivateKey:
+		return k
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"regexp"
+)
+
+// Ext the extension of detached signature files, `config.toml.sig` signs `config.toml`
+const Ext = ".sig"
+
+// ErrUnsigned the payload has neither a detached nor an embedded signature
+var ErrUnsigned = errors.New("config is not signed")
+
+// ErrInvalid the signature was not made by any of the trusted keys
+var ErrInvalid = errors.New("config signature is invalid")
+
+// embeddedLine the embedded signature line, a comment in the syntax of the signed document
+var embeddedLine = regexp.MustCompile(`^[ \t]*(?:#|//|;|<!--)[ \t]*signature:ed25519:([A-Za-z0-9+/]+=*)[ \t]*(?:-->)?[ \t]*\r?\n?$`)
+
+// Detached signs the payload and returns the content of the detached signature file
+func Detached(key ed25519.PrivateKey, payload []byte) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)) + "\n")
+}
+
+// Embed signs the payload and appends the signature as a comment line, comment is the line
+// comment prefix of the document syntax, `#` for TOML, YAML, HCL and properties, `;` for INI
+// and `<!--` for XML. JSON has no comments, it needs a detached signature.
+func Embed(key ed25519.PrivateKey, payload []byte, comment string) []byte {
+	signed := make([]byte, 0, len(payload)+128)
+	signed = append(signed, payload...)
+	if len(signed) > 0 && signed[len(signed)-1] != '\n' {
+		signed = append(signed, '\n')
+	}
+	line := comment + " signature:ed25519:" + base64.StdEncoding.EncodeToString(ed25519.Sign(key, signed))
+	if comment == "<!--" {
+		line += " -->"
+	}
+	return append(signed, line+"\n"...)
+}
+
+// SignFile writes the detached signature of the file next to it
+func SignFile(key ed25519.PrivateKey, path string) error {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+Ext, Detached(key, payload), 0644)
+}
+
+// Split splits the embedded signature line off the content, the signature is nil if there is none
+func Split(content []byte) (payload []byte, signature []byte) {
+	trimmed := bytes.TrimRight(content, "\r\n")
+	start := bytes.LastIndexByte(trimmed, '\n') + 1
+	m := embeddedLine.FindSubmatch(content[start:])
+	if m == nil {
+		return content, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(string(m[1]))
+	if err != nil {
+		return content, nil
+	}
+	return content[:start], signature
+}
+
+// Verify verifies the content against the trusted keys and returns the payload without the embedded
+// signature. The detached signature is used if not nil, otherwise the content must embed one.
+func Verify(keys []ed25519.PublicKey, content []byte, detached []byte) ([]byte, error) {
+	payload, signature := content, []byte(nil)
+	if detached != nil {
+		var err error
+		if signature, err = ParseSignature(detached); err != nil {
+			return nil, err
+		}
+	} else if payload, signature = Split(content); signature == nil {
+		return nil, ErrUnsigned
+	}
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, payload, signature) {
+			return payload, nil
+		}
+	}
+	return nil, ErrInvalid
+}
+
+// ParseSignature parses a detached signature, raw or encoded in base64
+func ParseSignature(b []byte) ([]byte, error) {
+	if len(b) == ed25519.SignatureSize {
+		return b, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, errors.New("config signature is malformed")
+	}
+	return signature, nil
+}
@@ -0,0 +1,105 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{b}, ed25519.SeedSize))
+}
+
+func TestVerify(t *testing.T) {
+	key, other := testKey(1), testKey(2)
+	trusted := []ed25519.PublicKey{key.Public().(ed25519.PublicKey)}
+	payload := []byte("[server]\nport = 8080\n")
+	embedded := Embed(key, payload, "#")
+	tests := []struct {
+		name        string
+		content     []byte
+		detached    []byte
+		wantPayload []byte
+		wantErr     error
+	}{
+		{name: "detached", content: payload, detached: Detached(key, payload), wantPayload: payload},
+		{name: "detached raw", content: payload, detached: ed25519.Sign(key, payload), wantPayload: payload},
+		{name: "embedded", content: embedded, wantPayload: payload},
+		{name: "embedded without trailing newline", content: Embed(key, []byte("a = 1"), "#"), wantPayload: []byte("a = 1\n")},
+		{name: "embedded xml", content: Embed(key, []byte("<a/>\n"), "<!--"), wantPayload: []byte("<a/>\n")},
+		{name: "embedded ini", content: Embed(key, []byte("a=1\n"), ";"), wantPayload: []byte("a=1\n")},
+		{name: "unsigned", content: payload, wantErr: ErrUnsigned},
+		{name: "untrusted key", content: payload, detached: Detached(other, payload), wantErr: ErrInvalid},
+		{name: "tampered payload", content: []byte("[server]\nport = 9090\n"), detached: Detached(key, payload), wantErr: ErrInvalid},
+		{name: "tampered embedded", content: bytes.Replace(embedded, []byte("8080"), []byte("9090"), 1), wantErr: ErrInvalid},
+		{name: "line appended after the signature", content: append(append([]byte{}, embedded...), "debug = true\n"...), wantErr: ErrUnsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(trusted, tt.content, tt.detached)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.wantPayload) {
+				t.Errorf("Verify() = %q, want %q", got, tt.wantPayload)
+			}
+		})
+	}
+	if _, err := Verify(trusted, payload, []byte("not a signature")); err == nil {
+		t.Error("Verify() with a malformed signature succeeded")
+	}
+	if _, err := Verify(nil, payload, Detached(key, payload)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Verify() without trusted keys error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	signature := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, ed25519.SignatureSize))
+	tests := []struct {
+		name          string
+		content       string
+		wantPayload   string
+		wantSignature bool
+	}{
+		{name: "hash comment", content: "a = 1\n# signature:ed25519:" + signature + "\n", wantPayload: "a = 1\n", wantSignature: true},
+		{name: "slash comment crlf", content: "a = 1\r\n// signature:ed25519:" + signature + "\r\n", wantPayload: "a = 1\r\n", wantSignature: true},
+		{name: "xml comment", content: "<a/>\n<!-- signature:ed25519:" + signature + " -->", wantPayload: "<a/>\n", wantSignature: true},
+		{name: "not the last line", content: "# signature:ed25519:" + signature + "\na = 1\n", wantPayload: "# signature:ed25519:" + signature + "\na = 1\n"},
+		{name: "invalid base64", content: "a = 1\n# signature:ed25519:abc=d\n", wantPayload: "a = 1\n# signature:ed25519:abc=d\n"},
+		{name: "no signature", content: "a = 1\n", wantPayload: "a = 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, signature := Split([]byte(tt.content))
+			if string(payload) != tt.wantPayload {
+				t.Errorf("Split() payload = %q, want %q", payload, tt.wantPayload)
+			}
+			if (signature != nil) != tt.wantSignature {
+				t.Errorf("Split() signature = %x, want signature %v", signature, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestSignFile(t *testing.T) {
+	key := testKey(1)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	payload := []byte("a = 1\n")
+	if err := os.WriteFile(path, payload, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SignFile(key, path); err != nil {
+		t.Fatal(err)
+	}
+	detached, err := os.ReadFile(path + Ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify([]ed25519.PublicKey{key.Public().(ed25519.PublicKey)}, payload, detached); err != nil {
+		t.Errorf("Verify() the signed file error = %v", err)
+	}
+}
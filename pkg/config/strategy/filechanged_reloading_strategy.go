@@ -4,6 +4,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 	"github.com/jacksonCLyu/ridi-utils/utils/fileutil"
 	"github.com/pkg/errors"
@@ -15,9 +16,18 @@ const DefaultTriggerInterval = 5000 * time.Millisecond
 // DefaultFileChangedReloadingStrategy is a strategy that reloads the configuration
 var DefaultFileChangedReloadingStrategy = NewFileChangedReloadingStrategy()
 
+// FileSystemAware is implemented by reloading strategies which detect changes through a file system
+type FileSystemAware interface {
+	// SetFileSystem set the file system of the configuration
+	SetFileSystem(fileSystem filesystem.FileSystem)
+}
+
+var _ FileSystemAware = (*FileChangedReloadingStrategy)(nil)
+
 // FileChangedReloadingStrategy file change reloading strategy
 type FileChangedReloadingStrategy struct {
 	configuration   configer.FileConfiguration
+	fileSystem      filesystem.FileSystem
 	lastModified    time.Duration
 	lastChecked     time.Duration
 	triggerInterval time.Duration
@@ -38,6 +48,7 @@ func NewFileChangedReloadingStrategy(opts ...FileChangedReloadingOption) confige
 	}
 	return &FileChangedReloadingStrategy{
 		configuration:   options.fileConfiguration,
+		fileSystem:      options.fileSystem,
 		lastModified:    0,
 		lastChecked:     0,
 		triggerInterval: options.triggerInterval,
@@ -46,17 +57,22 @@ func NewFileChangedReloadingStrategy(opts ...FileChangedReloadingOption) confige
 }
 
 // SetConfiguration set configuration
-func (s FileChangedReloadingStrategy) SetConfiguration(configuration configer.FileConfiguration) {
+func (s *FileChangedReloadingStrategy) SetConfiguration(configuration configer.FileConfiguration) {
 	s.configuration = configuration
 }
 
+// SetFileSystem set the file system used to detect changes of the configuration file
+func (s *FileChangedReloadingStrategy) SetFileSystem(fileSystem filesystem.FileSystem) {
+	s.fileSystem = fileSystem
+}
+
 // Init init fileConfiguration
-func (s FileChangedReloadingStrategy) Init() error {
+func (s *FileChangedReloadingStrategy) Init() error {
 	return s.updateLastModified()
 }
 
 // NeedReloading judge if need reloading the configuration
-func (s FileChangedReloadingStrategy) NeedReloading() (bool, error) {
+func (s *FileChangedReloadingStrategy) NeedReloading() (bool, error) {
 	if !s.reloading {
 		now := time.Now().Local().UnixMilli()
 		if now > s.lastChecked.Milliseconds()+s.triggerInterval.Milliseconds() {
@@ -71,38 +87,47 @@ func (s FileChangedReloadingStrategy) NeedReloading() (bool, error) {
 }
 
 // ReloadingPerformed the callback of reloading configuration performed
-func (s FileChangedReloadingStrategy) ReloadingPerformed() error {
+func (s *FileChangedReloadingStrategy) ReloadingPerformed() error {
 	return s.updateLastModified()
 }
 
-func (s FileChangedReloadingStrategy) updateLastModified() error {
+func (s *FileChangedReloadingStrategy) updateLastModified() error {
 	defer func() {
 		s.reloading = false
 	}()
-	var file *os.File
-	var fileInfo os.FileInfo
-	var gErr error
-	file, gErr = s.getFile()
-	if gErr != nil {
-		return gErr
-	}
-	fileInfo, gErr = file.Stat()
-	if gErr != nil {
-		return gErr
+	fileInfo, err := s.stat()
+	if err != nil {
+		return err
 	}
 	modTime := fileInfo.ModTime()
 	s.lastModified = time.Duration(modTime.Local().UnixMilli())
-	return gErr
+	return nil
+}
+
+// stat stats the configuration file through the file system if it supports it, otherwise through the file URL
+func (s *FileChangedReloadingStrategy) stat() (os.FileInfo, error) {
+	if s.configuration == nil {
+		return nil, errors.New("reloading strategy doesn't have file configuration")
+	}
+	if stater, ok := s.fileSystem.(filesystem.Stater); ok && s.configuration.GetFilePath() != "" {
+		return stater.Stat(s.configuration.GetFilePath())
+	}
+	file, err := s.getFile()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
 }
 
-func (s FileChangedReloadingStrategy) getFile() (*os.File, error) {
+func (s *FileChangedReloadingStrategy) getFile() (*os.File, error) {
 	if s.configuration.GetURL() != nil {
 		return s.getFileFromURL()
 	}
 	return nil, errors.New("file configuration doesn't have `URL` property")
 }
 
-func (s FileChangedReloadingStrategy) getFileFromURL() (*os.File, error) {
+func (s *FileChangedReloadingStrategy) getFileFromURL() (*os.File, error) {
 	url, err := fileutil.GetFileFromURL(s.configuration.GetURL())
 	if err != nil {
 		return nil, err
@@ -110,17 +135,10 @@ func (s FileChangedReloadingStrategy) getFileFromURL() (*os.File, error) {
 	return url, err
 }
 
-func (s FileChangedReloadingStrategy) hasChanged() (bool, error) {
-	var file *os.File
-	var fileInfo os.FileInfo
-	var gErr error
-	file, gErr = s.getFile()
-	if gErr != nil {
-		return false, gErr
-	}
-	fileInfo, gErr = file.Stat()
-	if gErr != nil {
-		return false, gErr
+func (s *FileChangedReloadingStrategy) hasChanged() (bool, error) {
+	fileInfo, err := s.stat()
+	if err != nil {
+		return false, err
 	}
 	modTime := fileInfo.ModTime()
 	return modTime.Local().UnixMilli() > s.lastModified.Milliseconds(), nil
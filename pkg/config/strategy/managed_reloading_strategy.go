@@ -28,20 +28,20 @@ func (s *managedReloadingStrategy) SetConfiguration(fileConfig configer.FileConf
 	s.configuration = fileConfig
 }
 
-func (s managedReloadingStrategy) Init() error {
+func (s *managedReloadingStrategy) Init() error {
 	return nil
 }
 
-func (s managedReloadingStrategy) NeedReloading() (bool, error) {
+func (s *managedReloadingStrategy) NeedReloading() (bool, error) {
 	return s.needReload, nil
 }
 
-func (s managedReloadingStrategy) ReloadingPerformed() error {
+func (s *managedReloadingStrategy) ReloadingPerformed() error {
 	s.needReload = false
 	return nil
 }
 
-func (s managedReloadingStrategy) Refresh() {
+func (s *managedReloadingStrategy) Refresh() {
 	s.needReload = true
 	s.configuration = nil
 }
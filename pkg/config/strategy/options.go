@@ -3,12 +3,14 @@ package strategy
 import (
 	"time"
 
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
 	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
 )
 
 type fileChangedReloadingOptions struct {
 	fileConfiguration configer.FileConfiguration
 	triggerInterval   time.Duration
+	fileSystem        filesystem.FileSystem
 }
 
 type managedReloadingOptions struct {
@@ -35,6 +37,11 @@ func WithTriggerInterval(triggerInterval time.Duration) FileChangedReloadingOpti
 	return triggerIntervalOption(triggerInterval)
 }
 
+// WithFileSystem sets the file system used to detect changes of the configuration file
+func WithFileSystem(fileSystem filesystem.FileSystem) FileChangedReloadingOption {
+	return fileSystemOption{fileSystem: fileSystem}
+}
+
 // WithManagedConfiguration sets the file configuration to use for the reloading strategy.
 func WithManagedConfiguration(configuration configer.FileConfiguration) ManagedReloadingOption {
 	return managedConfigurationOption{configuration: configuration}
@@ -61,3 +68,11 @@ type triggerIntervalOption time.Duration
 func (o triggerIntervalOption) apply(opts *fileChangedReloadingOptions) {
 	opts.triggerInterval = time.Duration(o)
 }
+
+type fileSystemOption struct {
+	fileSystem filesystem.FileSystem
+}
+
+func (o fileSystemOption) apply(opts *fileChangedReloadingOptions) {
+	opts.fileSystem = o.fileSystem
+}
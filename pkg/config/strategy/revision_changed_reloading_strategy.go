@@ -0,0 +1,103 @@
+package strategy
+
+import (
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+	"github.com/pkg/errors"
+)
+
+var _ FileSystemAware = (*RevisionChangedReloadingStrategy)(nil)
+
+// RevisionChangedReloadingStrategy reloads the configuration when the revision reported by
+// a filesystem.Revisioner changes, e.g. when the ref of a git file system moves
+type RevisionChangedReloadingStrategy struct {
+	configuration   configer.FileConfiguration
+	fileSystem      filesystem.FileSystem
+	revision        string
+	lastChecked     time.Duration
+	triggerInterval time.Duration
+	reloading       bool
+}
+
+// NewRevisionChangedReloadingStrategy creates a new RevisionChangedReloadingStrategy
+func NewRevisionChangedReloadingStrategy(opts ...FileChangedReloadingOption) configer.ReloadingStrategy {
+	options := &fileChangedReloadingOptions{}
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	if options.triggerInterval == 0 {
+		options.triggerInterval = DefaultTriggerInterval
+	}
+	return &RevisionChangedReloadingStrategy{
+		configuration:   options.fileConfiguration,
+		fileSystem:      options.fileSystem,
+		triggerInterval: options.triggerInterval,
+	}
+}
+
+// SetConfiguration set configuration
+func (s *RevisionChangedReloadingStrategy) SetConfiguration(configuration configer.FileConfiguration) {
+	s.configuration = configuration
+}
+
+// SetFileSystem set the file system which reports the revisions
+func (s *RevisionChangedReloadingStrategy) SetFileSystem(fileSystem filesystem.FileSystem) {
+	s.fileSystem = fileSystem
+}
+
+// Init init the current revision
+func (s *RevisionChangedReloadingStrategy) Init() error {
+	return s.updateRevision()
+}
+
+// NeedReloading judge if the revision has changed
+func (s *RevisionChangedReloadingStrategy) NeedReloading() (bool, error) {
+	if !s.reloading {
+		now := time.Now().Local().UnixMilli()
+		if now > s.lastChecked.Milliseconds()+s.triggerInterval.Milliseconds() {
+			s.lastChecked = time.Duration(now)
+			revision, err := s.getRevision()
+			if err != nil {
+				return false, err
+			}
+			s.reloading = revision != s.revision
+		}
+	}
+	return s.reloading, nil
+}
+
+// ReloadingPerformed the callback of reloading configuration performed
+func (s *RevisionChangedReloadingStrategy) ReloadingPerformed() error {
+	return s.updateRevision()
+}
+
+func (s *RevisionChangedReloadingStrategy) updateRevision() error {
+	defer func() {
+		s.reloading = false
+	}()
+	if loaded, ok := s.fileSystem.(filesystem.LoadedRevisioner); ok && s.configuration != nil {
+		if revision, ok := loaded.LoadedRevision(s.configuration.GetFilePath()); ok {
+			s.revision = revision
+			return nil
+		}
+	}
+	revision, err := s.getRevision()
+	if err != nil {
+		return err
+	}
+	s.revision = revision
+	return nil
+}
+
+func (s *RevisionChangedReloadingStrategy) getRevision() (string, error) {
+	if s.configuration == nil {
+		return "", errors.New("reloading strategy doesn't have file configuration")
+	}
+	revisioner, ok := s.fileSystem.(filesystem.Revisioner)
+	if !ok {
+		return "", errors.New("file system doesn't support revisions")
+	}
+	return revisioner.Revision(s.configuration.GetFilePath())
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Validator validates the staged configuration before an update is committed
+type Validator func(configurable configer.Configurable) error
+
+// Updatable is implemented by configurations which support batch updates
+type Updatable interface {
+	// Update applies all changes staged by fn atomically and persists them once
+	Update(fn func(tx *Tx) error) error
+	// SetInMemory sets a runtime override which is never written to disk
+	SetInMemory(key string, value any) error
+}
+
+var _ Updatable = (*config)(nil)
+
+// Tx is a configuration transaction, changes are staged on copies of the
+// configuration and only become visible once the transaction is committed
+type Tx struct {
+	configMap map[string]configer.Field
+	overrides map[string]configer.Field
+	// defaults read-only defaults layer
+	defaults map[string]configer.Field
+	// normalize resolve keys case, `_` and `-` insensitive
+	normalize bool
+	// decrypter decrypts staged encrypted values, new values of encrypted keys are encrypted with it
+	decrypter crypt.Decrypter
+	// secrets resolves staged secret references
+	secrets *secret.Resolver
+	// dirty reports whether the persisted configuration has been changed
+	dirty bool
+	// overridden reports whether the runtime overrides have been changed
+	overridden bool
+}
+
+// Set sets the value of the key path, missing parent sections are created
+func (tx *Tx) Set(key string, value any) error {
+	path, err := ParsePath(key)
+	if err != nil {
+		return err
+	}
+	return tx.SetPath(path, value)
+}
+
+// SetInMemory sets a runtime override of the key which is never written to disk
+func (tx *Tx) SetInMemory(key string, value any) error {
+	path, err := ParsePath(key)
+	if err != nil {
+		return err
+	}
+	if err := setPath(tx.overrides, tx.resolve(tx.overrides, path), configer.Atof(value)); err != nil {
+		return err
+	}
+	tx.overridden = true
+	return nil
+}
+
+// Delete removes the key and its runtime override
+func (tx *Tx) Delete(key string) error {
+	path, err := ParsePath(key)
+	if err != nil {
+		return err
+	}
+	return tx.DeletePath(path)
+}
+
+// SetPath is like Set but takes a parsed path, the value of an encrypted key is encrypted
+func (tx *Tx) SetPath(path Path, value any) error {
+	path = tx.resolve(tx.configMap, path)
+	if old, ok := getPath(tx.configMap, path); ok {
+		var err error
+		if value, err = encryptValue(old, path, value, tx.decrypter); err != nil {
+			return errors.New("config key:`" + path.String() + "` " + err.Error())
+		}
+	}
+	if err := setPath(tx.configMap, path, configer.Atof(value)); err != nil {
+		return err
+	}
+	tx.dirty = true
+	return nil
+}
+
+// DeletePath is like Delete but takes a parsed path
+func (tx *Tx) DeletePath(path Path) error {
+	deleted, err := deletePath(tx.configMap, tx.resolve(tx.configMap, path))
+	if err != nil {
+		return err
+	}
+	overridden, err := deletePath(tx.overrides, tx.resolve(tx.overrides, path))
+	if err != nil {
+		return err
+	}
+	if !deleted && !overridden {
+		return errors.New("config not found for key:`" + path.String() + "`")
+	}
+	tx.dirty = tx.dirty || deleted
+	tx.overridden = tx.overridden || overridden
+	return nil
+}
+
+// Get returns the staged value of the key
+func (tx *Tx) Get(key string) (any, error) {
+	staged := &config{configMap: tx.configMap, overrides: tx.overrides, defaults: tx.defaults, normalizeKeys: tx.normalize, decrypter: tx.decrypter, secrets: tx.secrets}
+	f, err := staged.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return f.Value, nil
+}
+
+// resolve resolves aliases and normalized keys of the path against the config map
+func (tx *Tx) resolve(configMap map[string]configer.Field, path Path) Path {
+	path, _ = resolveAlias(path)
+	if tx.normalize {
+		return resolvePath(configMap, path)
+	}
+	return path
+}
+
+// Update runs fn inside a transaction, validates the staged configuration and
+// persists it once. Nothing is changed if fn, validation or persisting fails.
+// fn must not call methods of the configuration itself, the lock is held.
+func (c *config) Update(fn func(tx *Tx) error) error {
+	changes, err := c.update(fn)
+	if err != nil {
+		return err
+	}
+	c.notify(changes)
+	return nil
+}
+
+// update runs the transaction and returns the changes of the watched keys
+func (c *config) update(fn func(tx *Tx) error) ([]Change, error) {
+	c.Lock()
+	defer c.Unlock()
+	tx := &Tx{
+		configMap: field.CopyMap(c.configMap),
+		overrides: field.CopyMap(c.overrides),
+		defaults:  c.defaults,
+		normalize: c.normalizeKeys,
+		decrypter: c.decrypter,
+		secrets:   c.secrets,
+	}
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	if tx.dirty || tx.overridden {
+		staged := &config{configMap: tx.configMap, overrides: tx.overrides, defaults: tx.defaults, normalizeKeys: tx.normalize, decrypter: tx.decrypter, secrets: tx.secrets}
+		for _, validate := range c.validators {
+			if err := validate(staged); err != nil {
+				return nil, err
+			}
+		}
+	}
+	before := c.snapshot()
+	configMap, overrides := c.configMap, c.overrides
+	c.configMap, c.overrides = tx.configMap, tx.overrides
+	if tx.dirty {
+		if err := c.commit(configMap); err != nil {
+			// rollback
+			c.configMap, c.overrides = configMap, overrides
+			return nil, err
+		}
+	}
+	c.decrypted.reset()
+	return c.changes(before), nil
+}
+
+// commit persists the committed config map, the caller must hold the lock
+func (c *config) commit(from map[string]configer.Field) error {
+	if c.persist != nil {
+		return c.persist(from, c.configMap)
+	}
+	return c.save(c.FilePath)
+}
+
+// SetInMemory sets a runtime override of the key which is never written to disk
+func (c *config) SetInMemory(key string, value any) error {
+	return c.Update(func(tx *Tx) error {
+		return tx.SetInMemory(key, value)
+	})
+}
+
+func updatable() (Updatable, error) {
+	u, ok := L().(Updatable)
+	if !ok {
+		return nil, errors.New("default config does not support updates")
+	}
+	return u, nil
+}
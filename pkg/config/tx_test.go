@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/strategy"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// newTestConfig writes the document to a temporary file and loads it without reloading
+func newTestConfig(t *testing.T, name string, content string, opts ...Option) *config {
+	t.Helper()
+	opts = append([]Option{
+		WithFilePath(writeTestFile(t, name, content)),
+		WithReloadingStrategy(strategy.NewManagedReloadingStrategy()),
+	}, opts...)
+	c, err := NewConfig(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*config)
+}
+
+// writeTestFile writes the content to a file of the name in a temporary directory
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUpdate(t *testing.T) {
+	maxPort := func(c configer.Configurable) error {
+		port, err := c.GetInt64("server.port")
+		if err != nil {
+			return err
+		}
+		if port > 9000 {
+			return errors.New("port too large")
+		}
+		return nil
+	}
+	tests := []struct {
+		name     string
+		fn       func(tx *Tx) error
+		wantErr  bool
+		wantPort int64
+		wantDisk string
+	}{
+		{
+			name:     "set is committed and persisted",
+			fn:       func(tx *Tx) error { return tx.Set("server.port", int64(8081)) },
+			wantPort: 8081,
+			wantDisk: "8081",
+		},
+		{
+			name: "fn error rolls back",
+			fn: func(tx *Tx) error {
+				if err := tx.Set("server.port", int64(8081)); err != nil {
+					return err
+				}
+				return errors.New("abort")
+			},
+			wantErr:  true,
+			wantPort: 8080,
+			wantDisk: "8080",
+		},
+		{
+			name:     "invalid set is rejected",
+			fn:       func(tx *Tx) error { return tx.Set("server.port", int64(9999)) },
+			wantErr:  true,
+			wantPort: 8080,
+			wantDisk: "8080",
+		},
+		{
+			name:     "in-memory override is not persisted",
+			fn:       func(tx *Tx) error { return tx.SetInMemory("server.port", int64(8082)) },
+			wantPort: 8082,
+			wantDisk: "8080",
+		},
+		{
+			name:     "invalid in-memory override is rejected",
+			fn:       func(tx *Tx) error { return tx.SetInMemory("server.port", int64(9999)) },
+			wantErr:  true,
+			wantPort: 8080,
+			wantDisk: "8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.toml", "[server]\nport = 8080\n", WithValidator(maxPort))
+			err := c.Update(tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			port, err := c.GetInt64("server.port")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if port != tt.wantPort {
+				t.Errorf("port = %d, want %d", port, tt.wantPort)
+			}
+			b, err := os.ReadFile(c.FilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), tt.wantDisk) {
+				t.Errorf("file = %q, want port %s", b, tt.wantDisk)
+			}
+		})
+	}
+}
+
+func TestTxDeleteOverride(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "[server]\nport = 8080\n")
+	if err := c.SetInMemory("server.port", int64(8082)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Update(func(tx *Tx) error {
+		if got, err := tx.Get("server.port"); err != nil || got != int64(8082) {
+			t.Errorf("tx.Get() = %v, %v, want override 8082", got, err)
+		}
+		return tx.Delete("server.port")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if c.ContainsKey("server.port") {
+		t.Error("server.port still exists after delete")
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding/field"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/secret"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Value is a configuration value which masks sensitive keys when it is formatted, marshaled or logged,
+// so it can be passed to loggers safely. Interface returns the unmasked value.
+type Value struct {
+	// Key the key path of the value
+	Key string
+	// Source the layer the value comes from: the file path, SourceDefaults or SourceMemory
+	Source string
+	value  any
+	masked any
+}
+
+// newValue returns the value of the field at the path, sensitive keys nested in sections are masked as well
+func newValue(path Path, f configer.Field, source string) Value {
+	v := field.Ftoa(f)
+	return Value{Key: path.String(), Source: source, value: v, masked: mask(path, v)}
+}
+
+// Interface returns the unmasked value
+func (v Value) Interface() any {
+	return v.value
+}
+
+// String returns the masked value
+func (v Value) String() string {
+	return fmt.Sprint(v.masked)
+}
+
+// Format formats the masked value
+func (v Value) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, formatString(f, verb), v.masked)
+}
+
+// MarshalJSON marshals the masked value
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.masked)
+}
+
+// formatString rebuilds the format directive of the state
+func formatString(f fmt.State, verb rune) string {
+	var sb strings.Builder
+	sb.WriteByte('%')
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			sb.WriteRune(flag)
+		}
+	}
+	if width, ok := f.Width(); ok {
+		sb.WriteString(strconv.Itoa(width))
+	}
+	if precision, ok := f.Precision(); ok {
+		sb.WriteString("." + strconv.Itoa(precision))
+	}
+	sb.WriteRune(verb)
+	return sb.String()
+}
+
+// mask returns the plain value with the values of sensitive keys replaced by secret.Redacted
+func mask(path Path, v any) any {
+	if isSensitive(path) {
+		return secret.Redacted
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, child := range v {
+			masked[key] = mask(path.Child(key), child)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, elem := range v {
+			masked[i] = mask(append(path[:len(path):len(path)], Segment{Index: i, IsIndex: true}), elem)
+		}
+		return masked
+	case secret.Value:
+		return secret.Redacted
+	}
+	return v
+}
@@ -0,0 +1,10 @@
+//go:build go1.21
+
+package config
+
+import "log/slog"
+
+// LogValue returns the masked value for structured logging
+func (v Value) LogValue() slog.Value {
+	return slog.AnyValue(v.masked)
+}
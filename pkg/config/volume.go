@@ -0,0 +1,79 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/encoding"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/filesystem"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/strategy"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// NewVolumeConfig creates a configuration from a mounted Kubernetes ConfigMap or Secret volume,
+// every file is a key and separator in file names nests keys (`db__host` with `__` becomes
+// `db.host`), an empty separator disables nesting. Values are parsed into typed fields and
+// the configuration reloads when the `..data` symlink flips.
+func NewVolumeConfig(dir string, separator string, opts ...Option) (configer.Configurable, error) {
+	options := DefaultOptions()
+	options.reloadingStrategy = strategy.NewRevisionChangedReloadingStrategy()
+	for _, opt := range opts {
+		opt.apply(options)
+	}
+	volume := filesystem.NewVolumeFS(dir)
+	c := &config{
+		FilePath:       dir,
+		ReloadStrategy: options.reloadingStrategy,
+		fileSystem:     volume,
+		overrides:      make(map[string]configer.Field),
+		validators:     options.validators,
+		normalizeKeys:  options.normalizeKeys,
+		decrypter:      options.decrypter,
+		secrets:        newSecretResolver(options),
+		encoder:        encoding.DefaultCodec,
+		decoder:        encoding.DefaultCodec,
+	}
+	defaults, err := loadDefaults(options.defaults)
+	if err != nil {
+		return nil, err
+	}
+	c.defaults = defaults
+	c.loader = func() (map[string]configer.Field, error) {
+		return loadVolume(volume, separator)
+	}
+	if c.configMap, err = c.loader(); err != nil {
+		return nil, err
+	}
+	if err := c.initReloadStrategy(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadVolume reads a snapshot of the volume into a config map
+func loadVolume(volume *filesystem.VolumeFileSystem, separator string) (map[string]configer.Field, error) {
+	snapshot, err := volume.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	configMap := make(map[string]configer.Field, len(snapshot.Names))
+	for _, name := range snapshot.Names {
+		levels := []string{name}
+		if separator != "" {
+			levels = strings.Split(name, separator)
+		}
+		path := make(Path, 0, len(levels))
+		for _, level := range levels {
+			if level != "" {
+				path = append(path, Segment{Key: level})
+			}
+		}
+		if len(path) == 0 {
+			continue
+		}
+		value := parseValue([]byte(strings.TrimRight(string(snapshot.Files[name]), "\r\n")))
+		if err := setPath(configMap, path, configer.Atof(value)); err != nil {
+			return nil, err
+		}
+	}
+	return configMap, nil
+}
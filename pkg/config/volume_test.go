@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jacksonCLyu/ridi-config/pkg/config/crypt"
+	"github.com/jacksonCLyu/ridi-config/pkg/config/strategy"
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// swapVolume writes the files into a new data directory and swaps `..data` to it atomically
+func swapVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	data := "..2024_" + version
+	if err := os.Mkdir(filepath.Join(dir, data), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, data, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.Symlink(data, filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// reloadVolume reloads the configuration once the trigger interval has passed
+func reloadVolume(t *testing.T, c *config) {
+	t.Helper()
+	time.Sleep(2 * time.Millisecond)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestVolumeConfig(t *testing.T, dir string) *config {
+	t.Helper()
+	reloading := strategy.NewRevisionChangedReloadingStrategy(strategy.WithTriggerInterval(time.Millisecond))
+	cfg, err := NewVolumeConfig(dir, "__", WithReloadingStrategy(reloading))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.(*config)
+}
+
+func TestVolumeConfig(t *testing.T) {
+	dir := t.TempDir()
+	swapVolume(t, dir, "1", map[string]string{"db__host": "localhost\n", "db__port": "5432", "debug": "true"})
+	c := newTestVolumeConfig(t, dir)
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{key: "db.host", want: "localhost"},
+		{key: "db.port", want: int64(5432)},
+		{key: "debug", want: true},
+	}
+	for _, tt := range tests {
+		if got, err := c.Get(tt.key); err != nil || got != tt.want {
+			t.Errorf("Get(%q) = %v, %v, want %v", tt.key, got, err, tt.want)
+		}
+	}
+	swapVolume(t, dir, "2", map[string]string{"db__host": "db", "db__port": "6543", "debug": "false"})
+	reloadVolume(t, c)
+	if got, err := c.GetInt64("db.port"); err != nil || got != 6543 {
+		t.Errorf("GetInt64(db.port) after the swap = %d, %v, want 6543", got, err)
+	}
+}
+
+func TestVolumeConfigSwapDuringReload(t *testing.T) {
+	dir := t.TempDir()
+	swapVolume(t, dir, "1", map[string]string{"n": "1"})
+	c := newTestVolumeConfig(t, dir)
+	load := c.loader
+	swapped := false
+	c.loader = func() (map[string]configer.Field, error) {
+		configMap, err := load()
+		if !swapped {
+			// the kubelet swaps again after the data has been read
+			swapped = true
+			swapVolume(t, dir, "3", map[string]string{"n": "3"})
+		}
+		return configMap, err
+	}
+	swapVolume(t, dir, "2", map[string]string{"n": "2"})
+	reloadVolume(t, c)
+	if got, _ := c.GetInt64("n"); got != 2 {
+		t.Fatalf("GetInt64(n) = %d, want 2", got)
+	}
+	reloadVolume(t, c)
+	if got, _ := c.GetInt64("n"); got != 3 {
+		t.Errorf("GetInt64(n) = %d, want the swap during the reload to be picked up", got)
+	}
+}
+
+func TestVolumeConfigReloadResetsDecrypted(t *testing.T) {
+	a := crypt.NewAESGCM(crypt.StaticKey(bytes.Repeat([]byte{1}, crypt.KeySize)))
+	encrypted, err := a.Encrypt("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	swapVolume(t, dir, "1", map[string]string{"password": encrypted})
+	reloading := strategy.NewRevisionChangedReloadingStrategy(strategy.WithTriggerInterval(time.Millisecond))
+	cfg, err := NewVolumeConfig(dir, "__", WithReloadingStrategy(reloading), WithDecrypter(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cfg.(*config)
+	if got, err := c.GetString("password"); err != nil || got != "s3cret" {
+		t.Fatalf("GetString(password) = %q, %v, want s3cret", got, err)
+	}
+	swapVolume(t, dir, "2", map[string]string{"password": "plain"})
+	reloadVolume(t, c)
+	if got, err := c.GetString("password"); err != nil || got != "plain" {
+		t.Errorf("GetString(password) after the swap = %q, %v, want plain", got, err)
+	}
+	c.decrypted.Lock()
+	defer c.decrypted.Unlock()
+	if len(c.decrypted.fields) != 0 {
+		t.Errorf("decrypted values = %v, want them dropped after the reload", c.decrypted.fields)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jacksonCLyu/ridi-faces/pkg/configer"
+)
+
+// Watchable is implemented by configurations which notify the changes of watched keys
+type Watchable interface {
+	// Watch watches the key paths, every key if none is given
+	Watch(paths ...string) error
+	// OnChange registers the handler of the changes of the watched keys
+	OnChange(handler func(changes []Change))
+}
+
+var _ Watchable = (*config)(nil)
+
+// watchers the watched key paths and the change handlers
+type watchers struct {
+	sync.Mutex
+	// all every key is watched
+	all      bool
+	paths    []Path
+	handlers []func(changes []Change)
+}
+
+// Watch watches the key paths, changes of the effective values at or under them are passed to the
+// OnChange handlers after an update, a load or a reload. Every key is watched if no path is given.
+func (c *config) Watch(paths ...string) error {
+	keys := make([]Path, 0, len(paths))
+	for _, path := range paths {
+		p, err := ParsePath(path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, p)
+	}
+	c.watchers.Lock()
+	defer c.watchers.Unlock()
+	if len(keys) == 0 {
+		c.watchers.all = true
+	}
+	c.watchers.paths = append(c.watchers.paths, keys...)
+	return nil
+}
+
+// OnChange registers the handler of the changes of the watched keys, handlers are called
+// without holding the lock so they may read the configuration
+func (c *config) OnChange(handler func(changes []Change)) {
+	c.watchers.Lock()
+	defer c.watchers.Unlock()
+	c.watchers.handlers = append(c.watchers.handlers, handler)
+}
+
+// snapshot returns the effective settings if changes are watched, the caller must hold the lock
+func (c *config) snapshot() map[string]configer.Field {
+	c.watchers.Lock()
+	watching := len(c.watchers.handlers) > 0 && (c.watchers.all || len(c.watchers.paths) > 0)
+	c.watchers.Unlock()
+	if !watching {
+		return nil
+	}
+	return c.settings()
+}
+
+// changes returns the changes of the watched keys since the snapshot, the caller must hold the lock
+func (c *config) changes(before map[string]configer.Field) []Change {
+	if before == nil {
+		return nil
+	}
+	after := c.settings()
+	var changes []Change
+	for _, change := range diffMaps(before, after) {
+		if c.watched(change.Key, before, after) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// watched reports whether the changed key is at or under a watched key path, or holds
+// a watched key path whose value has changed, such as an array element
+func (c *config) watched(key string, before, after map[string]configer.Field) bool {
+	c.watchers.Lock()
+	defer c.watchers.Unlock()
+	if c.watchers.all {
+		return true
+	}
+	for _, path := range c.watchers.paths {
+		watched := path.String()
+		if key == watched || strings.HasPrefix(key, watched+".") || strings.HasPrefix(key, watched+"[") {
+			return true
+		}
+		if strings.HasPrefix(watched, key+".") || strings.HasPrefix(watched, key+"[") {
+			old, _ := findPath(before, path, c.normalizeKeys)
+			value, _ := findPath(after, path, c.normalizeKeys)
+			if !reflect.DeepEqual(old, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notify passes the changes to the handlers, the lock must not be held
+func (c *config) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	c.watchers.Lock()
+	handlers := append([]func(changes []Change){}, c.watchers.handlers...)
+	c.watchers.Unlock()
+	for _, handler := range handlers {
+		handler(changes)
+	}
+}
+
+func watchable() (Watchable, error) {
+	w, ok := L().(Watchable)
+	if !ok {
+		return nil, errors.New("default config does not support watching")
+	}
+	return w, nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	const document = "name = \"app\"\nservers = [\"a\", \"b\"]\n[db]\nhost = \"localhost\"\nport = 5432\n"
+	tests := []struct {
+		name   string
+		paths  []string
+		change func(c *config) error
+		want   []Change
+	}{
+		{
+			name:   "set under a watched section",
+			paths:  []string{"db"},
+			change: func(c *config) error { return c.Set("db.port", int64(6543)) },
+			want:   []Change{{Type: ChangeModified, Key: "db.port", Old: int64(5432), New: int64(6543)}},
+		},
+		{
+			name:   "unwatched key",
+			paths:  []string{"db"},
+			change: func(c *config) error { return c.Set("name", "other") },
+		},
+		{
+			name:   "quoted and indexed paths",
+			paths:  []string{`"db".host`, "servers[1]"},
+			change: func(c *config) error { return c.Set("servers[1]", "c") },
+			want:   []Change{{Type: ChangeModified, Key: "servers", Old: []any{"a", "b"}, New: []any{"a", "c"}}},
+		},
+		{
+			name:   "other element of a watched array",
+			paths:  []string{"servers[1]"},
+			change: func(c *config) error { return c.Set("servers[0]", "c") },
+		},
+		{
+			name:   "in-memory override",
+			paths:  []string{"db.host"},
+			change: func(c *config) error { return c.SetInMemory("db.host", "remote") },
+			want:   []Change{{Type: ChangeModified, Key: "db.host", Old: "localhost", New: "remote"}},
+		},
+		{
+			name:  "every key on load",
+			paths: nil,
+			change: func(c *config) error {
+				if err := os.WriteFile(c.FilePath, []byte("name = \"app\"\n[db]\nhost = \"localhost\"\n"), 0644); err != nil {
+					return err
+				}
+				return c.Load(c.FilePath)
+			},
+			want: []Change{
+				{Type: ChangeRemoved, Key: "db.port", Old: int64(5432)},
+				{Type: ChangeRemoved, Key: "servers", Old: []any{"a", "b"}},
+			},
+		},
+		{
+			name:   "delete",
+			paths:  []string{"db.port"},
+			change: func(c *config) error { return c.Delete("db.port") },
+			want:   []Change{{Type: ChangeRemoved, Key: "db.port", Old: int64(5432)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConfig(t, "config.toml", document)
+			if err := c.Watch(tt.paths...); err != nil {
+				t.Fatal(err)
+			}
+			var got []Change
+			c.OnChange(func(changes []Change) {
+				// handlers may read the configuration
+				if _, err := c.Get("name"); err != nil {
+					t.Error(err)
+				}
+				got = append(got, changes...)
+			})
+			if err := tt.change(c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchWithoutHandler(t *testing.T) {
+	c := newTestConfig(t, "config.toml", "a = 1\n")
+	if err := c.Watch("a..b"); err == nil {
+		t.Error("Watch() of an invalid path succeeded")
+	}
+	if before := c.snapshot(); before != nil {
+		t.Error("settings are copied without change handlers")
+	}
+}